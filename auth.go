@@ -0,0 +1,395 @@
+package simplerouter
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrInvalidCredentials = errors.New("simplerouter: invalid credentials")
+	ErrSignatureExpired   = errors.New("simplerouter: request signature timestamp outside allowed skew")
+	ErrSignatureInvalid   = errors.New("simplerouter: request signature invalid")
+)
+
+type Principal struct {
+	ID          string
+	Method      string
+	Roles       []string
+	Permissions []string
+	Attributes  map[string]any
+}
+
+type principalKey struct{}
+
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+func PrincipalFrom(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalKey{}).(*Principal)
+	return principal, ok && principal != nil
+}
+
+func unauthorized(w http.ResponseWriter, challenge string) {
+	if challenge != "" {
+		w.Header().Set("WWW-Authenticate", challenge)
+	}
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
+
+type BasicAuthConfig struct {
+	Realm  string
+	Verify func(username, password string) bool
+}
+
+func BasicAuth(config BasicAuthConfig) Middleware {
+	if config.Verify == nil {
+		panic("simplerouter: BasicAuth requires a Verify function")
+	}
+	if config.Realm == "" {
+		config.Realm = "Restricted"
+	}
+	challenge := `Basic realm="` + strings.ReplaceAll(config.Realm, `"`, `\"`) + `", charset="UTF-8"`
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			username, password, ok := r.BasicAuth()
+			if !ok || !config.Verify(username, password) {
+				LogError(r, ErrInvalidCredentials)
+				unauthorized(w, challenge)
+				return
+			}
+
+			principal := &Principal{ID: username, Method: "basic"}
+			next(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+		}
+	}
+}
+
+// BasicUsers verifies against plaintext passwords. Both sides are hashed
+// before comparison so neither content nor length leaks through timing.
+func BasicUsers(users map[string]string) func(username, password string) bool {
+	hashed := make(map[string][32]byte, len(users))
+	for username, password := range users {
+		hashed[username] = sha256.Sum256([]byte(password))
+	}
+
+	return func(username, password string) bool {
+		expected, ok := hashed[username]
+		given := sha256.Sum256([]byte(password))
+		match := subtle.ConstantTimeCompare(expected[:], given[:]) == 1
+		return ok && match
+	}
+}
+
+// Htpasswd holds users loaded from an Apache htpasswd file. bcrypt ($2y$,
+// $2a$, $2b$) and {SHA} entries are supported.
+type Htpasswd struct {
+	path  string
+	mu    sync.RWMutex
+	users map[string]string
+}
+
+// dummyBcryptHash keeps lookups of unknown users as slow as known ones. It
+// is generated on first use so importers that never verify htpasswd users
+// don't pay for it at init.
+var dummyBcryptHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("simplerouter"), bcrypt.DefaultCost)
+	return hash
+})
+
+func LoadHtpasswd(path string) (*Htpasswd, error) {
+	h := &Htpasswd{path: path}
+	if err := h.Reload(); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
+
+func (h *Htpasswd) Reload() error {
+	file, err := os.Open(h.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	users, err := parseHtpasswd(file)
+	if err != nil {
+		return fmt.Errorf("%s: %w", h.path, err)
+	}
+
+	h.mu.Lock()
+	h.users = users
+	h.mu.Unlock()
+	return nil
+}
+
+func parseHtpasswd(r io.Reader) (map[string]string, error) {
+	users := make(map[string]string)
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		username, hash, ok := strings.Cut(text, ":")
+		if !ok || username == "" {
+			return nil, fmt.Errorf("line %d: malformed entry", line)
+		}
+		if !strings.HasPrefix(hash, "$2") && !strings.HasPrefix(hash, "{SHA}") {
+			return nil, fmt.Errorf("line %d: unsupported hash for user %q", line, username)
+		}
+		users[username] = hash
+	}
+	return users, scanner.Err()
+}
+
+func (h *Htpasswd) Verify(username, password string) bool {
+	h.mu.RLock()
+	hash, ok := h.users[username]
+	h.mu.RUnlock()
+
+	if !ok {
+		bcrypt.CompareHashAndPassword(dummyBcryptHash(), []byte(password))
+		return false
+	}
+
+	if strings.HasPrefix(hash, "{SHA}") {
+		sum := sha1.Sum([]byte(password))
+		given := base64.StdEncoding.EncodeToString(sum[:])
+		return subtle.ConstantTimeCompare([]byte(given), []byte(hash[len("{SHA}"):])) == 1
+	}
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+type APIKeyValidator interface {
+	ValidateAPIKey(ctx context.Context, key string) (*Principal, error)
+}
+
+type APIKeyConfig struct {
+	Header      string
+	QueryParam  string
+	AllowBearer bool
+	Validator   APIKeyValidator
+}
+
+func APIKeyAuth(config APIKeyConfig) Middleware {
+	if config.Validator == nil {
+		panic("simplerouter: APIKeyAuth requires a Validator")
+	}
+	if config.Header == "" {
+		config.Header = "X-API-Key"
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			key := config.extract(r)
+			if key == "" {
+				unauthorized(w, config.challenge())
+				return
+			}
+
+			principal, err := config.Validator.ValidateAPIKey(r.Context(), key)
+			if err != nil || principal == nil {
+				LogError(r, ErrInvalidCredentials)
+				unauthorized(w, config.challenge())
+				return
+			}
+			// Validators may hand out a cached Principal; never write to it.
+			copied := *principal
+			if copied.Method == "" {
+				copied.Method = "api_key"
+			}
+			next(w, r.WithContext(WithPrincipal(r.Context(), &copied)))
+		}
+	}
+}
+
+func (c APIKeyConfig) extract(r *http.Request) string {
+	if key := r.Header.Get(c.Header); key != "" {
+		return key
+	}
+	if c.AllowBearer {
+		if scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " "); ok && strings.EqualFold(scheme, "Bearer") {
+			return strings.TrimSpace(token)
+		}
+	}
+	if c.QueryParam != "" {
+		return r.URL.Query().Get(c.QueryParam)
+	}
+	return ""
+}
+
+func (c APIKeyConfig) challenge() string {
+	if c.AllowBearer {
+		return "Bearer"
+	}
+	return ""
+}
+
+type staticAPIKeys map[[32]byte]*Principal
+
+// StaticAPIKeys validates against a fixed set of keys. Keys are stored and
+// looked up by SHA-256 digest so comparison time does not depend on content.
+func StaticAPIKeys(keys map[string]*Principal) APIKeyValidator {
+	hashed := make(staticAPIKeys, len(keys))
+	for key, principal := range keys {
+		hashed[sha256.Sum256([]byte(key))] = principal
+	}
+	return hashed
+}
+
+func (s staticAPIKeys) ValidateAPIKey(ctx context.Context, key string) (*Principal, error) {
+	principal, ok := s[sha256.Sum256([]byte(key))]
+	if !ok {
+		return nil, ErrInvalidCredentials
+	}
+	copied := *principal
+	return &copied, nil
+}
+
+type HMACKeyStore interface {
+	HMACKey(ctx context.Context, keyID string) (secret []byte, principal *Principal, err error)
+}
+
+type HMACAuthConfig struct {
+	Keys        HMACKeyStore
+	MaxSkew     time.Duration
+	MaxBodySize int64
+	now         func() time.Time
+}
+
+const (
+	signatureKeyIDHeader     = "X-Signature-Key-Id"
+	signatureTimestampHeader = "X-Signature-Timestamp"
+	signatureHeader          = "X-Signature"
+)
+
+// HMACAuth verifies requests signed with SignRequest: an HMAC-SHA256 over the
+// method, request URI, unix timestamp and body digest.
+func HMACAuth(config HMACAuthConfig) Middleware {
+	if config.Keys == nil {
+		panic("simplerouter: HMACAuth requires a key store")
+	}
+	if config.MaxSkew <= 0 {
+		config.MaxSkew = 5 * time.Minute
+	}
+	if config.MaxBodySize <= 0 {
+		config.MaxBodySize = 10 << 20
+	}
+	if config.now == nil {
+		config.now = time.Now
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			principal, err := config.verify(r)
+			if err != nil {
+				LogError(r, err)
+				unauthorized(w, "")
+				return
+			}
+			if principal.Method == "" {
+				principal.Method = "hmac"
+			}
+			next(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+		}
+	}
+}
+
+func (c HMACAuthConfig) verify(r *http.Request) (*Principal, error) {
+	keyID := r.Header.Get(signatureKeyIDHeader)
+	signature, err := hex.DecodeString(r.Header.Get(signatureHeader))
+	if keyID == "" || err != nil || len(signature) == 0 {
+		return nil, ErrSignatureInvalid
+	}
+
+	timestamp, err := strconv.ParseInt(r.Header.Get(signatureTimestampHeader), 10, 64)
+	if err != nil {
+		return nil, ErrSignatureInvalid
+	}
+	skew := c.now().Sub(time.Unix(timestamp, 0))
+	if skew > c.MaxSkew || skew < -c.MaxSkew {
+		return nil, ErrSignatureExpired
+	}
+
+	secret, principal, err := c.Keys.HMACKey(r.Context(), keyID)
+	if err != nil || principal == nil {
+		return nil, ErrSignatureInvalid
+	}
+
+	body, err := readAndRestoreBody(r, c.MaxBodySize)
+	if err != nil {
+		return nil, err
+	}
+
+	expected := requestSignature(secret, r.Method, r.URL.RequestURI(), timestamp, body)
+	if !hmac.Equal(signature, expected) {
+		return nil, ErrSignatureInvalid
+	}
+
+	copied := *principal
+	return &copied, nil
+}
+
+// SignRequest adds HMACAuth signature headers to an outgoing request.
+func SignRequest(r *http.Request, keyID string, secret []byte) error {
+	body, err := readAndRestoreBody(r, -1)
+	if err != nil {
+		return err
+	}
+	timestamp := time.Now().Unix()
+	r.Header.Set(signatureKeyIDHeader, keyID)
+	r.Header.Set(signatureTimestampHeader, strconv.FormatInt(timestamp, 10))
+	r.Header.Set(signatureHeader, hex.EncodeToString(requestSignature(secret, r.Method, r.URL.RequestURI(), timestamp, body)))
+	return nil
+}
+
+func requestSignature(secret []byte, method, requestURI string, timestamp int64, body []byte) []byte {
+	bodyHash := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, secret)
+	fmt.Fprintf(mac, "%s\n%s\n%d\n%s", method, requestURI, timestamp, hex.EncodeToString(bodyHash[:]))
+	return mac.Sum(nil)
+}
+
+func readAndRestoreBody(r *http.Request, limit int64) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+
+	reader := io.Reader(r.Body)
+	if limit >= 0 {
+		reader = io.LimitReader(r.Body, limit+1)
+	}
+	body, err := io.ReadAll(reader)
+	r.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if limit >= 0 && int64(len(body)) > limit {
+		return nil, &http.MaxBytesError{Limit: limit}
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
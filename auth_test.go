@@ -0,0 +1,245 @@
+package simplerouter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+func principalHandler(w http.ResponseWriter, r *http.Request) {
+	principal, ok := PrincipalFrom(r.Context())
+	if !ok {
+		http.Error(w, "no principal", http.StatusInternalServerError)
+		return
+	}
+	w.Write([]byte(principal.Method + ":" + principal.ID))
+}
+
+func TestBasicAuth(t *testing.T) {
+	router := New().Use(BasicAuth(BasicAuthConfig{
+		Realm:  "admin",
+		Verify: BasicUsers(map[string]string{"alice": "s3cret"}),
+	}))
+	router.GET("/", principalHandler)
+
+	tests := []struct {
+		name       string
+		user, pass string
+		status     int
+	}{
+		{"valid", "alice", "s3cret", http.StatusOK},
+		{"wrong password", "alice", "nope", http.StatusUnauthorized},
+		{"unknown user", "bob", "s3cret", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.SetBasicAuth(tt.user, tt.pass)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d", tt.status, rr.Code)
+			}
+			if tt.status == http.StatusOK && rr.Body.String() != "basic:alice" {
+				t.Errorf("Expected principal basic:alice, got %q", rr.Body.String())
+			}
+		})
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	if got := rr.Header().Get("WWW-Authenticate"); got != `Basic realm="admin", charset="UTF-8"` {
+		t.Errorf("Unexpected challenge %q", got)
+	}
+}
+
+func TestHtpasswd(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("bcrypt-pass"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), ".htpasswd")
+	content := "# users\nalice:" + string(hash) + "\nbob:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	htpasswd, err := LoadHtpasswd(path)
+	if err != nil {
+		t.Fatalf("LoadHtpasswd: %v", err)
+	}
+
+	if !htpasswd.Verify("alice", "bcrypt-pass") {
+		t.Error("Expected bcrypt user to verify")
+	}
+	if !htpasswd.Verify("bob", "password") {
+		t.Error("Expected {SHA} user to verify")
+	}
+	if htpasswd.Verify("alice", "password") || htpasswd.Verify("carol", "password") {
+		t.Error("Expected wrong password and unknown user to fail")
+	}
+
+	if err := os.WriteFile(path, []byte("bob:{SHA}W6ph5Mm5Pz8GgiULbPgzG37mj9g=\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := htpasswd.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if htpasswd.Verify("alice", "bcrypt-pass") {
+		t.Error("Expected removed user to fail after reload")
+	}
+
+	if err := os.WriteFile(path, []byte("carol:plaintext\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadHtpasswd(path); err == nil {
+		t.Error("Expected error for unsupported hash format")
+	}
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	router := New().Use(APIKeyAuth(APIKeyConfig{
+		QueryParam:  "api_key",
+		AllowBearer: true,
+		Validator: StaticAPIKeys(map[string]*Principal{
+			"key-123": {ID: "service-a"},
+		}),
+	}))
+	router.GET("/", principalHandler)
+
+	tests := []struct {
+		name   string
+		setup  func(r *http.Request)
+		target string
+		status int
+	}{
+		{"header", func(r *http.Request) { r.Header.Set("X-API-Key", "key-123") }, "/", http.StatusOK},
+		{"bearer", func(r *http.Request) { r.Header.Set("Authorization", "Bearer key-123") }, "/", http.StatusOK},
+		{"query", func(r *http.Request) {}, "/?api_key=key-123", http.StatusOK},
+		{"invalid", func(r *http.Request) { r.Header.Set("X-API-Key", "wrong") }, "/", http.StatusUnauthorized},
+		{"missing", func(r *http.Request) {}, "/", http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", tt.target, nil)
+			tt.setup(req)
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d", tt.status, rr.Code)
+			}
+			if tt.status == http.StatusOK && rr.Body.String() != "api_key:service-a" {
+				t.Errorf("Expected principal api_key:service-a, got %q", rr.Body.String())
+			}
+		})
+	}
+}
+
+type sharedPrincipalValidator struct {
+	principal *Principal
+}
+
+func (v sharedPrincipalValidator) ValidateAPIKey(ctx context.Context, key string) (*Principal, error) {
+	return v.principal, nil
+}
+
+func TestAPIKeyAuthSharedPrincipal(t *testing.T) {
+	shared := &Principal{ID: "service-a"}
+	router := New().Use(APIKeyAuth(APIKeyConfig{
+		Validator: sharedPrincipalValidator{principal: shared},
+	}))
+	router.GET("/", principalHandler)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("X-API-Key", "any")
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if rr.Body.String() != "api_key:service-a" {
+				t.Errorf("Expected principal api_key:service-a, got %q", rr.Body.String())
+			}
+		}()
+	}
+	wg.Wait()
+
+	if shared.Method != "" {
+		t.Errorf("Expected validator's principal to be left untouched, got Method %q", shared.Method)
+	}
+}
+
+type testHMACKeys map[string][]byte
+
+func (k testHMACKeys) HMACKey(ctx context.Context, keyID string) ([]byte, *Principal, error) {
+	secret, ok := k[keyID]
+	if !ok {
+		return nil, nil, errors.New("unknown key")
+	}
+	return secret, &Principal{ID: keyID}, nil
+}
+
+func TestHMACAuth(t *testing.T) {
+	secret := []byte("shared-secret")
+	router := New().Use(HMACAuth(HMACAuthConfig{Keys: testHMACKeys{"client-1": secret}}))
+	router.POST("/orders", func(w http.ResponseWriter, r *http.Request) {
+		body := make([]byte, 64)
+		n, _ := r.Body.Read(body)
+		principalHandler(w, r)
+		w.Write([]byte(" " + string(body[:n])))
+	})
+
+	newSigned := func() *http.Request {
+		req := httptest.NewRequest("POST", "/orders?id=7", strings.NewReader(`{"qty":1}`))
+		if err := SignRequest(req, "client-1", secret); err != nil {
+			t.Fatal(err)
+		}
+		return req
+	}
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, newSigned())
+	if rr.Code != http.StatusOK || rr.Body.String() != `hmac:client-1 {"qty":1}` {
+		t.Fatalf("Expected signed request to pass with body intact, got %d %q", rr.Code, rr.Body.String())
+	}
+
+	tampered := newSigned()
+	tampered.Body = httptest.NewRequest("POST", "/", strings.NewReader(`{"qty":100}`)).Body
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, tampered)
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected tampered body to be rejected, got %d", rr.Code)
+	}
+
+	stale := newSigned()
+	stale.Header.Set("X-Signature-Timestamp", strconv.FormatInt(time.Now().Add(-10*time.Minute).Unix(), 10))
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, stale)
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected stale timestamp to be rejected, got %d", rr.Code)
+	}
+
+	unknown := newSigned()
+	unknown.Header.Set("X-Signature-Key-Id", "client-2")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, unknown)
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected unknown key to be rejected, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,117 @@
+package simplerouter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+var ErrForbidden = errors.New("simplerouter: principal lacks required permission")
+
+// Authorizer decides whether principal satisfies every requirement declared
+// on the matched route. A non-nil error results in 403 Forbidden.
+type Authorizer interface {
+	Authorize(ctx context.Context, principal *Principal, requirements []string) error
+}
+
+type AuthorizerFunc func(ctx context.Context, principal *Principal, requirements []string) error
+
+func (f AuthorizerFunc) Authorize(ctx context.Context, principal *Principal, requirements []string) error {
+	return f(ctx, principal, requirements)
+}
+
+// PermissionAuthorizer grants a requirement when it appears in the
+// principal's Permissions, in the permissions RolePermissions assigns to one
+// of its Roles, or when it has the form "role:<name>" and the principal holds
+// that role. A permission ending in ":*" covers every permission with that
+// prefix, so "orders:*" satisfies "orders:write".
+type PermissionAuthorizer struct {
+	RolePermissions map[string][]string
+}
+
+func (a PermissionAuthorizer) Authorize(ctx context.Context, principal *Principal, requirements []string) error {
+	granted := slices.Clone(principal.Permissions)
+	for _, role := range principal.Roles {
+		granted = append(granted, "role:"+role)
+		granted = append(granted, a.RolePermissions[role]...)
+	}
+
+	for _, requirement := range requirements {
+		if !slices.ContainsFunc(granted, func(permission string) bool {
+			return permissionMatches(permission, requirement)
+		}) {
+			return ErrForbidden
+		}
+	}
+	return nil
+}
+
+func permissionMatches(permission, requirement string) bool {
+	if prefix, ok := strings.CutSuffix(permission, "*"); ok && strings.HasSuffix(prefix, ":") {
+		return strings.HasPrefix(requirement, prefix)
+	}
+	return permission == requirement
+}
+
+type authorizerKey struct{}
+
+// Authorization selects the Authorizer that enforces route requirements for
+// the routes it wraps. Routes with requirements but no Authorization
+// middleware are checked with a zero PermissionAuthorizer.
+func Authorization(authorizer Authorizer) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			next(w, r.WithContext(context.WithValue(r.Context(), authorizerKey{}, authorizer)))
+		}
+	}
+}
+
+// enforceRequirements runs innermost, after every route middleware, so
+// authentication middleware registered anywhere in the chain has already
+// placed the Principal into the context.
+func enforceRequirements(requirements []string, next HandlerFunc) HandlerFunc {
+	requirements = slices.Clone(requirements)
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := PrincipalFrom(r.Context())
+		if !ok {
+			unauthorized(w, "")
+			return
+		}
+
+		authorizer, ok := r.Context().Value(authorizerKey{}).(Authorizer)
+		if !ok {
+			authorizer = PermissionAuthorizer{}
+		}
+		if err := authorizer.Authorize(r.Context(), principal, requirements); err != nil {
+			LogError(r, err)
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// Require returns a router whose routes demand every listed permission in
+// addition to any already required by r.
+func (r *Router) Require(permissions ...string) *Router {
+	router := r.Use()
+	router.requires = appendRequirements(r.requires, permissions)
+	return router
+}
+
+func (rb *RouteBuilder) Require(permissions ...string) *RouteBuilder {
+	rb.requires = appendRequirements(rb.requires, permissions)
+	return rb
+}
+
+func appendRequirements(existing, permissions []string) []string {
+	requires := slices.Clone(existing)
+	for _, permission := range permissions {
+		if !slices.Contains(requires, permission) {
+			requires = append(requires, permission)
+		}
+	}
+	return requires
+}
@@ -0,0 +1,149 @@
+package simplerouter
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func principalFromHeader(next HandlerFunc) HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if id := r.Header.Get("X-Test-User"); id != "" {
+			principal := &Principal{
+				ID:          id,
+				Roles:       strings.Fields(r.Header.Get("X-Test-Roles")),
+				Permissions: strings.Fields(r.Header.Get("X-Test-Permissions")),
+			}
+			r = r.WithContext(WithPrincipal(r.Context(), principal))
+		}
+		next(w, r)
+	}
+}
+
+func TestRequire(t *testing.T) {
+	router := New().Use(principalFromHeader, Authorization(PermissionAuthorizer{
+		RolePermissions: map[string][]string{"clerk": {"orders:read"}},
+	}))
+
+	orders := router.Group("/orders").Require("orders:read")
+	orders.GET("", okHandler)
+	orders.Route("/{id}").Require("orders:write").PUT(okHandler)
+	router.Route("/admin").Require("role:admin").GET(okHandler)
+	router.GET("/open", okHandler)
+
+	tests := []struct {
+		name        string
+		method      string
+		path        string
+		roles       string
+		permissions string
+		anonymous   bool
+		status      int
+	}{
+		{"anonymous", "GET", "/orders", "", "", true, http.StatusUnauthorized},
+		{"no permission", "GET", "/orders", "", "", false, http.StatusForbidden},
+		{"direct permission", "GET", "/orders", "", "orders:read", false, http.StatusOK},
+		{"permission via role", "GET", "/orders", "clerk", "", false, http.StatusOK},
+		{"group and route requirements", "PUT", "/orders/1", "clerk", "", false, http.StatusForbidden},
+		{"wildcard permission", "PUT", "/orders/1", "", "orders:*", false, http.StatusOK},
+		{"role requirement", "GET", "/admin", "admin", "", false, http.StatusOK},
+		{"missing role", "GET", "/admin", "clerk", "", false, http.StatusForbidden},
+		{"no requirement", "GET", "/open", "", "", true, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if !tt.anonymous {
+				req.Header.Set("X-Test-User", "u1")
+				req.Header.Set("X-Test-Roles", tt.roles)
+				req.Header.Set("X-Test-Permissions", tt.permissions)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rr.Code)
+			}
+		})
+	}
+}
+
+func TestRequireCustomAuthorizer(t *testing.T) {
+	var seen []string
+	authorizer := AuthorizerFunc(func(ctx context.Context, principal *Principal, requirements []string) error {
+		seen = requirements
+		if principal.ID != "root" {
+			return errors.New("denied")
+		}
+		return nil
+	})
+
+	router := New().Use(principalFromHeader, Authorization(authorizer))
+	router.Route("/reports").Require("reports:read", "reports:export").GET(okHandler)
+
+	for id, status := range map[string]int{"root": http.StatusOK, "guest": http.StatusForbidden} {
+		req := httptest.NewRequest("GET", "/reports", nil)
+		req.Header.Set("X-Test-User", id)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != status {
+			t.Errorf("%s: expected status %d, got %d", id, status, rr.Code)
+		}
+	}
+	if !slices.Equal(seen, []string{"reports:read", "reports:export"}) {
+		t.Errorf("Expected authorizer to receive route requirements, got %v", seen)
+	}
+}
+
+func TestRequireIsolation(t *testing.T) {
+	router := New()
+	api := router.Group("/api")
+	api.Require("admin").GET("/secret", okHandler)
+	api.GET("/public", okHandler)
+
+	requires := map[string][]string{}
+	for _, info := range router.Routes() {
+		requires[info.Path] = info.Requires
+	}
+	if !slices.Equal(requires["/api/secret"], []string{"admin"}) {
+		t.Errorf("Expected /api/secret to require admin, got %v", requires["/api/secret"])
+	}
+	if len(requires["/api/public"]) != 0 {
+		t.Errorf("Expected Require to not leak into parent group, got %v", requires["/api/public"])
+	}
+
+	router.Routes()[0].Requires[0] = "tampered"
+	if info := router.Routes()[0]; info.Requires[0] != "admin" {
+		t.Errorf("Expected Routes to return a copy, got %v", info.Requires)
+	}
+	req := httptest.NewRequest("GET", "/api/secret", nil)
+	req = req.WithContext(WithPrincipal(req.Context(), &Principal{ID: "u1", Permissions: []string{"tampered"}}))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusForbidden {
+		t.Errorf("Expected mutating Routes output to leave enforcement unchanged, got %d", rr.Code)
+	}
+
+	stdout := os.Stdout
+	r, w, _ := os.Pipe()
+	os.Stdout = w
+	router.PrintRoutes()
+	w.Close()
+	os.Stdout = stdout
+	output, _ := io.ReadAll(r)
+
+	if !strings.Contains(string(output), "Requires") || !strings.Contains(string(output), "admin") {
+		t.Errorf("Expected PrintRoutes to list requirements, got:\n%s", output)
+	}
+}
+
+func okHandler(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("ok"))
+}
@@ -0,0 +1,142 @@
+package simplerouter
+
+import (
+	"bytes"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+var defaultCaptureContentTypes = []string{
+	"text/",
+	"application/json",
+	"application/xml",
+	"application/x-www-form-urlencoded",
+	"application/problem+json",
+	"+json",
+	"+xml",
+}
+
+type BodyCaptureConfig struct {
+	MaxBytes     int
+	ContentTypes []string
+}
+
+func (c *BodyCaptureConfig) limit() int {
+	if c.MaxBytes > 0 {
+		return c.MaxBytes
+	}
+	return 4096
+}
+
+func (c *BodyCaptureConfig) capturable(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	types := c.ContentTypes
+	if len(types) == 0 {
+		types = defaultCaptureContentTypes
+	}
+	for _, t := range types {
+		if strings.HasPrefix(t, "+") {
+			if strings.HasSuffix(mediaType, t) {
+				return true
+			}
+		} else if strings.HasPrefix(mediaType, t) {
+			return true
+		}
+	}
+	return false
+}
+
+type bodyCapture struct {
+	mu        sync.Mutex
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (c *bodyCapture) Write(p []byte) (int, error) {
+	c.capture(p)
+	return len(p), nil
+}
+
+func (c *bodyCapture) capture(p []byte) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	remaining := c.limit - c.buf.Len()
+	if len(p) > remaining {
+		p = p[:remaining]
+		c.truncated = true
+	}
+	c.buf.Write(p)
+}
+
+func (c *bodyCapture) body(contentType string, config *BodyCaptureConfig, redaction *RedactionConfig) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.buf.Len() == 0 {
+		return "", false
+	}
+	if contentType == "" {
+		contentType = http.DetectContentType(c.buf.Bytes())
+	}
+	if !config.capturable(contentType) {
+		return "", false
+	}
+
+	data := c.buf.Bytes()
+	if c.truncated {
+		data = trimPartialRune(data)
+	}
+	if len(data) == 0 || !utf8.Valid(data) {
+		return "", false
+	}
+
+	body := string(data)
+	if redaction != nil {
+		body = redaction.redactBody(body, contentType)
+	}
+	return body, c.truncated
+}
+
+// trimPartialRune drops an incomplete multi-byte rune that truncation left
+// at the end of data.
+func trimPartialRune(data []byte) []byte {
+	for i := 1; i < utf8.UTFMax && i <= len(data); i++ {
+		if start := len(data) - i; utf8.RuneStart(data[start]) {
+			if !utf8.FullRune(data[start:]) {
+				return data[:start]
+			}
+			break
+		}
+	}
+	return data
+}
+
+func (c *RedactionConfig) redactBody(body, contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		return c.redactQuery(body)
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		fields := c.jsonFields
+		if fields == nil {
+			fields = jsonFieldPattern(c.QueryParams)
+		}
+		if fields != nil {
+			body = fields.ReplaceAllString(body, `${1}"`+c.mask()+`"`)
+		}
+	}
+	return c.redactString(body)
+}
@@ -0,0 +1,153 @@
+package simplerouter
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAccessLoggingBodyCapture(t *testing.T) {
+	var buf bytes.Buffer
+	router := New().Use(AccessLogging(AccessLogConfig{
+		Output:      &buf,
+		Format:      JSONLogFormat,
+		BodyCapture: &BodyCaptureConfig{MaxBytes: 32},
+		Redaction:   DefaultRedaction(),
+	}))
+
+	router.POST("/login", func(w http.ResponseWriter, r *http.Request) {
+		io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"ok","detail":"` + strings.Repeat("x", 64) + `"}`))
+	})
+
+	router.POST("/upload", func(w http.ResponseWriter, r *http.Request) {
+		io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Write([]byte{0x00, 0x01, 0x02})
+	})
+
+	tests := []struct {
+		name              string
+		path              string
+		contentType       string
+		body              string
+		expectRequest     string
+		expectResponse    string
+		expectRespTrunc   bool
+		expectRespPresent bool
+	}{
+		{
+			name:              "json with redaction and truncation",
+			path:              "/login",
+			contentType:       "application/json",
+			body:              `{"user":"bob","password":"hunter2"}`,
+			expectRequest:     `{"user":"bob","password":"[REDACTED]"`,
+			expectResponse:    `{"status":"ok","detail":"xxxxxxx`,
+			expectRespTrunc:   true,
+			expectRespPresent: true,
+		},
+		{
+			name:        "binary bodies skipped",
+			path:        "/upload",
+			contentType: "image/png",
+			body:        "\x89PNG\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			req := httptest.NewRequest("POST", tt.path, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+
+			var entry AccessLogEntry
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("Failed to decode log entry: %v", err)
+			}
+			if entry.RequestBody != tt.expectRequest {
+				t.Errorf("Expected request body %q, got %q", tt.expectRequest, entry.RequestBody)
+			}
+			if entry.ResponseBody != tt.expectResponse {
+				t.Errorf("Expected response body %q, got %q", tt.expectResponse, entry.ResponseBody)
+			}
+			if entry.ResponseBodyTruncated != tt.expectRespTrunc {
+				t.Errorf("Expected response truncated=%v, got %v", tt.expectRespTrunc, entry.ResponseBodyTruncated)
+			}
+			if strings.Contains(buf.String(), "hunter2") {
+				t.Errorf("Expected password to be redacted, got %s", buf.String())
+			}
+			if tt.expectRespPresent && rr.Body.Len() <= 32 {
+				t.Errorf("Expected full response to reach the client, got %d bytes", rr.Body.Len())
+			}
+		})
+	}
+}
+
+type flushHijackRecorder struct {
+	*httptest.ResponseRecorder
+	hijacked bool
+}
+
+func (f *flushHijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	f.hijacked = true
+	return nil, nil, nil
+}
+
+func TestAccessLoggingBodyCapturePassthrough(t *testing.T) {
+	var buf bytes.Buffer
+	router := New().Use(AccessLogging(AccessLogConfig{
+		Output:      &buf,
+		Format:      JSONLogFormat,
+		BodyCapture: &BodyCaptureConfig{},
+	}))
+
+	router.GET("/stream", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("chunk"))
+		w.(http.Flusher).Flush()
+		w.(http.Hijacker).Hijack()
+	})
+
+	rec := &flushHijackRecorder{ResponseRecorder: httptest.NewRecorder()}
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/stream", nil))
+
+	if !rec.Flushed {
+		t.Error("Expected Flush to pass through")
+	}
+	if !rec.hijacked {
+		t.Error("Expected Hijack to pass through")
+	}
+	if !strings.Contains(buf.String(), `"response_body":"chunk"`) {
+		t.Errorf("Expected sniffed text response to be captured, got %s", buf.String())
+	}
+}
+
+func TestBodyCaptureUTF8(t *testing.T) {
+	config := &BodyCaptureConfig{MaxBytes: 5}
+
+	capture := func(data string) *bodyCapture {
+		c := &bodyCapture{limit: config.limit()}
+		c.capture([]byte(data))
+		return c
+	}
+
+	// "abc€" is 6 bytes; the cap splits the 3-byte euro sign.
+	if body, truncated := capture("abc€").body("text/plain", config, nil); body != "abc" || !truncated {
+		t.Errorf("Expected split rune to be trimmed, got %q truncated=%v", body, truncated)
+	}
+	if body, _ := capture("a\xffb").body("text/plain", config, nil); body != "" {
+		t.Errorf("Expected invalid UTF-8 body to be skipped, got %q", body)
+	}
+	if body, _ := capture("ab\xffcdef").body("text/plain", config, nil); body != "" {
+		t.Errorf("Expected invalid byte before truncation to skip the body, got %q", body)
+	}
+}
@@ -0,0 +1,33 @@
+package simplerouter
+
+import (
+	"errors"
+	"net/http"
+)
+
+// BodyLimit rejects requests whose declared Content-Length exceeds limit with
+// 413 and caps the body read by handlers, which then see *http.MaxBytesError.
+func BodyLimit(limit int64) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > limit {
+				LogError(r, &http.MaxBytesError{Limit: limit})
+				http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+				return
+			}
+			if r.Body != nil && r.Body != http.NoBody {
+				r.Body = http.MaxBytesReader(w, r.Body, limit)
+			}
+			next(w, r)
+		}
+	}
+}
+
+func IsBodyTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
+
+func (rb *RouteBuilder) BodyLimit(limit int64) *RouteBuilder {
+	return rb.Use(BodyLimit(limit))
+}
@@ -0,0 +1,64 @@
+package simplerouter
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBodyLimit(t *testing.T) {
+	router := New()
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		data, err := io.ReadAll(r.Body)
+		if IsBodyTooLarge(err) {
+			http.Error(w, "body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		w.Write(data)
+	}
+
+	router.Group("/api").Use(BodyLimit(8)).POST("/json", handler)
+	router.Route("/upload").BodyLimit(64).POST(handler)
+
+	tests := []struct {
+		name          string
+		path          string
+		body          string
+		hideLength    bool
+		expectStatus  int
+		expectHandled bool
+	}{
+		{"within group limit", "/api/json", "small", false, http.StatusOK, true},
+		{"declared length over group limit", "/api/json", strings.Repeat("x", 9), false, http.StatusRequestEntityTooLarge, false},
+		{"streamed body over group limit", "/api/json", strings.Repeat("x", 9), true, http.StatusRequestEntityTooLarge, true},
+		{"larger route limit", "/upload", strings.Repeat("x", 64), false, http.StatusOK, true},
+		{"over route limit", "/upload", strings.Repeat("x", 65), false, http.StatusRequestEntityTooLarge, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body io.Reader = strings.NewReader(tt.body)
+			if tt.hideLength {
+				body = io.MultiReader(body)
+			}
+			req := httptest.NewRequest("POST", tt.path, body)
+			if tt.hideLength {
+				req.ContentLength = -1
+			}
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectStatus, rr.Code)
+			}
+			handled := strings.Contains(rr.Body.String(), "body too large") || rr.Code == http.StatusOK
+			if handled != tt.expectHandled {
+				t.Errorf("Expected handler reached=%v, got body %q", tt.expectHandled, rr.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package simplerouter
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CacheControl describes a Cache-Control response header. Durations are
+// rounded down to whole seconds; zero durations are omitted.
+type CacheControl struct {
+	Public               bool
+	Private              bool
+	NoCache              bool
+	NoStore              bool
+	MustRevalidate       bool
+	Immutable            bool
+	MaxAge               time.Duration
+	SharedMaxAge         time.Duration
+	StaleWhileRevalidate time.Duration
+	StaleIfError         time.Duration
+}
+
+// NoStoreCache forbids caching entirely, for responses with sensitive data.
+func NoStoreCache() CacheControl {
+	return CacheControl{NoStore: true}
+}
+
+// RevalidateCache lets clients keep a copy but revalidate it on every use,
+// which pairs with ETag for cheap 304 responses on polling endpoints.
+func RevalidateCache() CacheControl {
+	return CacheControl{NoCache: true}
+}
+
+// ImmutableCache is for fingerprinted static assets that never change.
+func ImmutableCache(maxAge time.Duration) CacheControl {
+	return CacheControl{Public: true, MaxAge: maxAge, Immutable: true}
+}
+
+func (c CacheControl) String() string {
+	var directives []string
+	add := func(enabled bool, directive string) {
+		if enabled {
+			directives = append(directives, directive)
+		}
+	}
+	addSeconds := func(d time.Duration, directive string) {
+		if d > 0 {
+			directives = append(directives, directive+"="+strconv.FormatInt(int64(d/time.Second), 10))
+		}
+	}
+
+	add(c.Public, "public")
+	add(c.Private, "private")
+	add(c.NoCache, "no-cache")
+	add(c.NoStore, "no-store")
+	addSeconds(c.MaxAge, "max-age")
+	addSeconds(c.SharedMaxAge, "s-maxage")
+	addSeconds(c.StaleWhileRevalidate, "stale-while-revalidate")
+	addSeconds(c.StaleIfError, "stale-if-error")
+	add(c.MustRevalidate, "must-revalidate")
+	add(c.Immutable, "immutable")
+	return strings.Join(directives, ", ")
+}
+
+func SetCacheControl(w http.ResponseWriter, c CacheControl) {
+	w.Header().Set("Cache-Control", c.String())
+}
+
+// Cache sets a default Cache-Control header on responses. Handlers can
+// override it by setting their own before writing.
+func Cache(c CacheControl) Middleware {
+	value := c.String()
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", value)
+			next(w, r)
+		}
+	}
+}
+
+func (rb *RouteBuilder) Cache(c CacheControl) *RouteBuilder {
+	return rb.Use(Cache(c))
+}
@@ -0,0 +1,59 @@
+package simplerouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCacheControlString(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   CacheControl
+		expected string
+	}{
+		{"no store", NoStoreCache(), "no-store"},
+		{"revalidate", RevalidateCache(), "no-cache"},
+		{"immutable", ImmutableCache(365 * 24 * time.Hour), "public, max-age=31536000, immutable"},
+		{"shared", CacheControl{
+			Public:               true,
+			MaxAge:               time.Minute,
+			SharedMaxAge:         5 * time.Minute,
+			StaleWhileRevalidate: 30 * time.Second,
+			MustRevalidate:       true,
+		}, "public, max-age=60, s-maxage=300, stale-while-revalidate=30, must-revalidate"},
+		{"private", CacheControl{Private: true, MaxAge: 1500 * time.Millisecond}, "private, max-age=1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.String(); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestCacheMiddleware(t *testing.T) {
+	router := New()
+	router.Route("/status").Cache(RevalidateCache()).GET(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	router.Route("/account").Cache(RevalidateCache()).GET(func(w http.ResponseWriter, r *http.Request) {
+		SetCacheControl(w, NoStoreCache())
+		w.Write([]byte("secret"))
+	})
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/status", nil))
+	if got := rr.Header().Get("Cache-Control"); got != "no-cache" {
+		t.Errorf("Expected default policy, got %q", got)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/account", nil))
+	if got := rr.Header().Get("Cache-Control"); got != "no-store" {
+		t.Errorf("Expected handler override, got %q", got)
+	}
+}
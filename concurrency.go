@@ -0,0 +1,184 @@
+package simplerouter
+
+import (
+	"container/list"
+	"context"
+	"math"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type ConcurrencyLimitConfig struct {
+	MaxInFlight  int
+	MaxQueue     int
+	QueueTimeout time.Duration
+
+	// Adaptive lowers the in-flight limit multiplicatively when request
+	// latency exceeds TargetLatency and raises it additively otherwise,
+	// staying between MinInFlight and MaxInFlight. The limit is lowered at
+	// most once per round of requests: slow requests that started before
+	// the last decrease do not lower it again.
+	Adaptive      bool
+	TargetLatency time.Duration
+	MinInFlight   int
+	Backoff       float64
+}
+
+type ConcurrencyLimiter struct {
+	config   ConcurrencyLimitConfig
+	mu       sync.Mutex
+	inFlight int
+	limit    float64
+	queue    *list.List
+	shed     atomic.Uint64
+
+	lastDecrease time.Time
+	now          func() time.Time
+}
+
+type concurrencyWaiter struct {
+	ready   chan struct{}
+	granted bool
+}
+
+func NewConcurrencyLimiter(config ConcurrencyLimitConfig) *ConcurrencyLimiter {
+	if config.MaxInFlight <= 0 {
+		panic("simplerouter: concurrency limit requires a positive MaxInFlight")
+	}
+	if config.MinInFlight <= 0 {
+		config.MinInFlight = 1
+	}
+	if config.Backoff <= 0 || config.Backoff >= 1 {
+		config.Backoff = 0.9
+	}
+	if config.Adaptive && config.TargetLatency <= 0 {
+		panic("simplerouter: adaptive concurrency limit requires a TargetLatency")
+	}
+
+	return &ConcurrencyLimiter{
+		config: config,
+		limit:  float64(config.MaxInFlight),
+		queue:  list.New(),
+		now:    time.Now,
+	}
+}
+
+func ConcurrencyLimit(config ConcurrencyLimitConfig) Middleware {
+	return NewConcurrencyLimiter(config).Middleware()
+}
+
+func (l *ConcurrencyLimiter) Middleware() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if !l.acquire(r.Context()) {
+				l.shed.Add(1)
+				w.Header().Set("Retry-After", "1")
+				http.Error(w, http.StatusText(http.StatusServiceUnavailable), http.StatusServiceUnavailable)
+				return
+			}
+
+			start := l.now()
+			defer func() {
+				l.release(start)
+			}()
+
+			next(w, r)
+		}
+	}
+}
+
+func (l *ConcurrencyLimiter) InFlight() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.inFlight
+}
+
+func (l *ConcurrencyLimiter) Limit() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return int(l.limit)
+}
+
+func (l *ConcurrencyLimiter) Shed() uint64 {
+	return l.shed.Load()
+}
+
+func (l *ConcurrencyLimiter) queueLen() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.queue.Len()
+}
+
+func (l *ConcurrencyLimiter) acquire(ctx context.Context) bool {
+	l.mu.Lock()
+	if l.inFlight < int(l.limit) && l.queue.Len() == 0 {
+		l.inFlight++
+		l.mu.Unlock()
+		return true
+	}
+	if l.queue.Len() >= l.config.MaxQueue {
+		l.mu.Unlock()
+		return false
+	}
+
+	waiter := &concurrencyWaiter{ready: make(chan struct{})}
+	elem := l.queue.PushBack(waiter)
+	l.mu.Unlock()
+
+	var timeout <-chan time.Time
+	if l.config.QueueTimeout > 0 {
+		timer := time.NewTimer(l.config.QueueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	select {
+	case <-waiter.ready:
+		return true
+	case <-timeout:
+	case <-ctx.Done():
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if waiter.granted {
+		// Granted concurrently with the timeout; hand the slot on.
+		l.inFlight--
+		l.grant()
+		return false
+	}
+	l.queue.Remove(elem)
+	return false
+}
+
+func (l *ConcurrencyLimiter) release(start time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inFlight--
+	if l.config.Adaptive {
+		now := l.now()
+		if now.Sub(start) > l.config.TargetLatency {
+			// Requests already running when the limit was last lowered
+			// reflect the old limit, so they must not lower it again.
+			if !start.Before(l.lastDecrease) {
+				l.limit = math.Max(float64(l.config.MinInFlight), l.limit*l.config.Backoff)
+				l.lastDecrease = now
+			}
+		} else {
+			l.limit = math.Min(float64(l.config.MaxInFlight), l.limit+1/l.limit)
+		}
+	}
+	l.grant()
+}
+
+func (l *ConcurrencyLimiter) grant() {
+	for l.inFlight < int(l.limit) && l.queue.Len() > 0 {
+		waiter := l.queue.Remove(l.queue.Front()).(*concurrencyWaiter)
+		waiter.granted = true
+		l.inFlight++
+		close(waiter.ready)
+	}
+}
@@ -0,0 +1,140 @@
+package simplerouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConcurrencyLimitQueue(t *testing.T) {
+	limiter := NewConcurrencyLimiter(ConcurrencyLimitConfig{
+		MaxInFlight:  1,
+		MaxQueue:     1,
+		QueueTimeout: time.Second,
+	})
+
+	release := make(chan struct{})
+	started := make(chan struct{}, 3)
+	router := New().Use(limiter.Middleware())
+	router.GET("/work", func(w http.ResponseWriter, r *http.Request) {
+		started <- struct{}{}
+		<-release
+	})
+
+	codes := make(chan int, 3)
+	var wg sync.WaitGroup
+	serve := func() {
+		defer wg.Done()
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/work", nil))
+		codes <- rr.Code
+	}
+
+	wg.Add(1)
+	go serve()
+	<-started
+
+	wg.Add(1)
+	go serve()
+	for limiter.queueLen() != 1 {
+		time.Sleep(time.Millisecond)
+	}
+
+	// Queue is full, so the third request is shed immediately.
+	wg.Add(1)
+	go serve()
+	if code := <-codes; code != http.StatusServiceUnavailable {
+		t.Fatalf("Expected status %d for overflow request, got %d", http.StatusServiceUnavailable, code)
+	}
+
+	close(release)
+	wg.Wait()
+	close(codes)
+	for code := range codes {
+		if code != http.StatusOK {
+			t.Errorf("Expected queued requests to succeed, got %d", code)
+		}
+	}
+	if limiter.Shed() != 1 {
+		t.Errorf("Expected 1 shed request, got %d", limiter.Shed())
+	}
+	if limiter.InFlight() != 0 {
+		t.Errorf("Expected no requests in flight, got %d", limiter.InFlight())
+	}
+}
+
+func TestConcurrencyLimitQueueTimeout(t *testing.T) {
+	release := make(chan struct{})
+	router := New()
+	router.GET("/work", func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}, ConcurrencyLimit(ConcurrencyLimitConfig{
+		MaxInFlight:  1,
+		MaxQueue:     5,
+		QueueTimeout: 10 * time.Millisecond,
+	}))
+
+	done := make(chan struct{})
+	go func() {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/work", nil))
+		close(done)
+	}()
+	time.Sleep(5 * time.Millisecond)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/work", nil))
+	if rr.Code != http.StatusServiceUnavailable || rr.Header().Get("Retry-After") == "" {
+		t.Errorf("Expected queued request to time out with 503 and Retry-After, got %d", rr.Code)
+	}
+
+	close(release)
+	<-done
+}
+
+func TestConcurrencyLimitAdaptive(t *testing.T) {
+	limiter := NewConcurrencyLimiter(ConcurrencyLimitConfig{
+		MaxInFlight:   10,
+		MinInFlight:   2,
+		Adaptive:      true,
+		TargetLatency: 5 * time.Millisecond,
+		Backoff:       0.5,
+	})
+	clock := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	limiter.now = func() time.Time { return clock }
+
+	// A burst of slow requests finishing together backs off only once.
+	starts := make([]time.Time, 5)
+	for i := range starts {
+		limiter.acquire(t.Context())
+		starts[i] = clock
+	}
+	clock = clock.Add(20 * time.Millisecond)
+	for _, start := range starts {
+		limiter.release(start)
+	}
+	if limiter.Limit() != 5 {
+		t.Errorf("Expected a single back-off to 5 for one slow round, got %d", limiter.Limit())
+	}
+
+	for i := 0; i < 5; i++ {
+		limiter.acquire(t.Context())
+		start := clock
+		clock = clock.Add(20 * time.Millisecond)
+		limiter.release(start)
+	}
+	if limiter.Limit() != 2 {
+		t.Errorf("Expected limit to back off to the minimum of 2, got %d", limiter.Limit())
+	}
+
+	for i := 0; i < 100; i++ {
+		limiter.acquire(t.Context())
+		start := clock
+		clock = clock.Add(time.Millisecond)
+		limiter.release(start)
+	}
+	if limiter.Limit() <= 2 {
+		t.Errorf("Expected limit to recover under low latency, got %d", limiter.Limit())
+	}
+}
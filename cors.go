@@ -0,0 +1,138 @@
+package simplerouter
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type CORSConfig struct {
+	// AllowedOrigins holds exact origins, "*", or wildcard subdomain
+	// patterns such as "https://*.example.com". "*" cannot be combined with
+	// AllowCredentials, since that would let any site read credentialed
+	// responses.
+	AllowedOrigins        []string
+	AllowedOriginPatterns []*regexp.Regexp
+	AllowOriginFunc       func(origin string, r *http.Request) bool
+
+	// AllowedHeaders defaults to echoing the headers a preflight asks for.
+	AllowedHeaders      []string
+	ExposedHeaders      []string
+	AllowCredentials    bool
+	MaxAge              time.Duration
+	AllowPrivateNetwork bool
+}
+
+// CORS answers preflight requests using the methods registered for the
+// matched path, so routes do not need explicit OPTIONS handlers.
+func CORS(config CORSConfig) Middleware {
+	allowAll := false
+	for _, origin := range config.AllowedOrigins {
+		if origin == "*" {
+			allowAll = true
+		}
+	}
+	if allowAll && config.AllowCredentials {
+		panic(`simplerouter: CORS AllowedOrigins "*" cannot be used with AllowCredentials`)
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			header := w.Header()
+
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+			if preflight {
+				header.Add("Vary", "Origin")
+				header.Add("Vary", "Access-Control-Request-Method")
+				header.Add("Vary", "Access-Control-Request-Headers")
+			} else {
+				header.Add("Vary", "Origin")
+			}
+
+			if origin == "" || !config.originAllowed(origin, r, allowAll) {
+				next(w, r)
+				return
+			}
+
+			if allowAll {
+				header.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				header.Set("Access-Control-Allow-Origin", origin)
+			}
+			if config.AllowCredentials {
+				header.Set("Access-Control-Allow-Credentials", "true")
+			}
+
+			if !preflight {
+				if len(config.ExposedHeaders) > 0 {
+					header.Set("Access-Control-Expose-Headers", strings.Join(config.ExposedHeaders, ", "))
+				}
+				next(w, r)
+				return
+			}
+
+			methods := routeMethodsFrom(r.Context())
+			if len(methods) == 0 {
+				methods = []string{r.Header.Get("Access-Control-Request-Method")}
+			}
+			header.Set("Access-Control-Allow-Methods", strings.Join(methods, ", "))
+
+			if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+				if allowed := config.allowedHeaders(requested); allowed != "" {
+					header.Set("Access-Control-Allow-Headers", allowed)
+				}
+			}
+			if config.MaxAge > 0 {
+				header.Set("Access-Control-Max-Age", strconv.Itoa(int(config.MaxAge.Seconds())))
+			}
+			if config.AllowPrivateNetwork && r.Header.Get("Access-Control-Request-Private-Network") == "true" {
+				header.Set("Access-Control-Allow-Private-Network", "true")
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}
+}
+
+func (c CORSConfig) originAllowed(origin string, r *http.Request, allowAll bool) bool {
+	if allowAll {
+		return true
+	}
+	for _, allowed := range c.AllowedOrigins {
+		if prefix, suffix, ok := strings.Cut(allowed, "*"); ok {
+			if len(origin) > len(prefix)+len(suffix) &&
+				strings.HasPrefix(origin, prefix) && strings.HasSuffix(origin, suffix) {
+				return true
+			}
+		} else if strings.EqualFold(origin, allowed) {
+			return true
+		}
+	}
+	for _, pattern := range c.AllowedOriginPatterns {
+		if pattern.MatchString(origin) {
+			return true
+		}
+	}
+	return c.AllowOriginFunc != nil && c.AllowOriginFunc(origin, r)
+}
+
+func (c CORSConfig) allowedHeaders(requested string) string {
+	if len(c.AllowedHeaders) == 0 {
+		return requested
+	}
+
+	var allowed []string
+	for _, name := range strings.Split(requested, ",") {
+		name = strings.TrimSpace(name)
+		for _, h := range c.AllowedHeaders {
+			if h == "*" || strings.EqualFold(h, name) {
+				allowed = append(allowed, name)
+				break
+			}
+		}
+	}
+	return strings.Join(allowed, ", ")
+}
@@ -0,0 +1,167 @@
+package simplerouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestCORSPreflight(t *testing.T) {
+	router := New().Use(CORS(CORSConfig{
+		AllowedOrigins:      []string{"https://app.example.com", "https://*.example.org"},
+		AllowedHeaders:      []string{"Content-Type", "Authorization"},
+		AllowCredentials:    true,
+		MaxAge:              10 * time.Minute,
+		AllowPrivateNetwork: true,
+	}))
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}
+	router.GET("/orders", handler)
+	router.POST("/orders", handler)
+
+	tests := []struct {
+		name          string
+		origin        string
+		expectAllowed bool
+	}{
+		{"exact origin", "https://app.example.com", true},
+		{"wildcard subdomain", "https://eu.example.org", true},
+		{"wildcard does not match apex", "https://example.org", false},
+		{"unknown origin", "https://evil.example.net", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("OPTIONS", "/orders", nil)
+			req.Header.Set("Origin", tt.origin)
+			req.Header.Set("Access-Control-Request-Method", "POST")
+			req.Header.Set("Access-Control-Request-Headers", "content-type, x-unknown")
+			req.Header.Set("Access-Control-Request-Private-Network", "true")
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != http.StatusNoContent {
+				t.Errorf("Expected status %d, got %d", http.StatusNoContent, rr.Code)
+			}
+
+			allowOrigin := rr.Header().Get("Access-Control-Allow-Origin")
+			if !tt.expectAllowed {
+				if allowOrigin != "" {
+					t.Errorf("Expected no Access-Control-Allow-Origin, got %q", allowOrigin)
+				}
+				return
+			}
+
+			if allowOrigin != tt.origin {
+				t.Errorf("Expected Access-Control-Allow-Origin %q, got %q", tt.origin, allowOrigin)
+			}
+			if got := rr.Header().Get("Access-Control-Allow-Methods"); got != "GET, OPTIONS, POST" {
+				t.Errorf("Expected route methods in Access-Control-Allow-Methods, got %q", got)
+			}
+			if got := rr.Header().Get("Access-Control-Allow-Headers"); got != "content-type" {
+				t.Errorf("Expected only allowed headers, got %q", got)
+			}
+			if rr.Header().Get("Access-Control-Allow-Credentials") != "true" {
+				t.Error("Expected credentials to be allowed")
+			}
+			if rr.Header().Get("Access-Control-Max-Age") != "600" {
+				t.Errorf("Expected max age 600, got %q", rr.Header().Get("Access-Control-Max-Age"))
+			}
+			if rr.Header().Get("Access-Control-Allow-Private-Network") != "true" {
+				t.Error("Expected private network access to be allowed")
+			}
+		})
+	}
+}
+
+func TestCORSActualRequest(t *testing.T) {
+	router := New().Use(CORS(CORSConfig{
+		AllowedOrigins: []string{"*"},
+		ExposedHeaders: []string{"X-Total-Count"},
+	}))
+
+	router.GET("/items", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("items"))
+	})
+
+	req := httptest.NewRequest("GET", "/items", nil)
+	req.Header.Set("Origin", "https://anywhere.test")
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	if rr.Body.String() != "items" {
+		t.Errorf("Expected handler to run, got %q", rr.Body.String())
+	}
+	if rr.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Errorf("Expected wildcard origin, got %q", rr.Header().Get("Access-Control-Allow-Origin"))
+	}
+	if rr.Header().Get("Access-Control-Expose-Headers") != "X-Total-Count" {
+		t.Errorf("Expected exposed headers, got %q", rr.Header().Get("Access-Control-Expose-Headers"))
+	}
+	if rr.Header().Get("Vary") != "Origin" {
+		t.Errorf("Expected Vary: Origin, got %q", rr.Header().Get("Vary"))
+	}
+}
+
+func TestCORSOriginMatchers(t *testing.T) {
+	config := CORSConfig{
+		AllowedOriginPatterns: []*regexp.Regexp{regexp.MustCompile(`^https://pr-\d+\.preview\.dev$`)},
+		AllowOriginFunc: func(origin string, r *http.Request) bool {
+			return origin == "https://partner.test"
+		},
+	}
+
+	tests := []struct {
+		origin string
+		expect bool
+	}{
+		{"https://pr-42.preview.dev", true},
+		{"https://pr-x.preview.dev", false},
+		{"https://partner.test", true},
+		{"https://other.test", false},
+	}
+
+	for _, tt := range tests {
+		if got := config.originAllowed(tt.origin, nil, false); got != tt.expect {
+			t.Errorf("originAllowed(%q) = %v, expected %v", tt.origin, got, tt.expect)
+		}
+	}
+}
+
+func TestCORSWildcardWithCredentials(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error(`Expected "*" with AllowCredentials to be rejected`)
+		}
+	}()
+	CORS(CORSConfig{AllowedOrigins: []string{"*"}, AllowCredentials: true})
+}
+
+func TestCORSPreflightRouteMiddleware(t *testing.T) {
+	router := New()
+	handler := func(w http.ResponseWriter, r *http.Request) {}
+
+	router.GET("/items", handler)
+	router.Route("/items").Use(CORS(CORSConfig{
+		AllowedOrigins: []string{"https://app.example.com"},
+	})).POST(handler)
+
+	req := httptest.NewRequest("OPTIONS", "/items", nil)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "POST")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("Expected status %d, got %d", http.StatusNoContent, rr.Code)
+	}
+	if got := rr.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Errorf("Expected the POST route's CORS middleware to answer the preflight, got origin %q", got)
+	}
+}
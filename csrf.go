@@ -0,0 +1,272 @@
+package simplerouter
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const csrfTokenLength = 32
+
+var (
+	ErrCSRFTokenMissing   = errors.New("simplerouter: CSRF token missing")
+	ErrCSRFTokenInvalid   = errors.New("simplerouter: CSRF token invalid")
+	ErrCSRFOriginMismatch = errors.New("simplerouter: CSRF origin not allowed")
+)
+
+type csrfTokenKey struct{}
+
+type CSRFConfig struct {
+	Secret []byte
+
+	CookieName   string
+	CookiePath   string
+	CookieDomain string
+	CookieMaxAge time.Duration
+	Secure       bool
+	SameSite     http.SameSite
+
+	HeaderName string
+	FormField  string
+
+	ExemptPaths    []string
+	Exempt         func(r *http.Request) bool
+	TrustedOrigins []string
+
+	// SessionID binds tokens to the caller's session by mixing its return
+	// value into the cookie signature, so a cookie planted by a sibling
+	// subdomain or issued to another session is rejected. It typically
+	// returns a session identifier or the Principal's ID.
+	SessionID func(r *http.Request) string
+
+	ErrorHandler func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// CSRF implements the signed double-submit cookie pattern. Unsafe requests
+// must echo the cookie's token in HeaderName or FormField and, when the
+// browser sends them, pass Sec-Fetch-Site and Origin checks.
+func CSRF(config CSRFConfig) Middleware {
+	if len(config.Secret) == 0 {
+		panic("simplerouter: CSRF requires a Secret")
+	}
+	if config.CookieName == "" {
+		config.CookieName = "csrf_token"
+	}
+	if config.CookiePath == "" {
+		config.CookiePath = "/"
+	}
+	if config.CookieMaxAge == 0 {
+		config.CookieMaxAge = 12 * time.Hour
+	}
+	if config.SameSite == 0 {
+		config.SameSite = http.SameSiteLaxMode
+	}
+	if config.HeaderName == "" {
+		config.HeaderName = "X-CSRF-Token"
+	}
+	if config.FormField == "" {
+		config.FormField = "csrf_token"
+	}
+	if config.ErrorHandler == nil {
+		config.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+			http.Error(w, "Forbidden - CSRF check failed", http.StatusForbidden)
+		}
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Cookie")
+
+			session := config.sessionID(r)
+			token, ok := config.cookieToken(r, session)
+			if !ok {
+				token = config.newToken(session)
+				http.SetCookie(w, &http.Cookie{
+					Name:     config.CookieName,
+					Value:    token,
+					Path:     config.CookiePath,
+					Domain:   config.CookieDomain,
+					MaxAge:   int(config.CookieMaxAge.Seconds()),
+					Secure:   config.Secure,
+					HttpOnly: true,
+					SameSite: config.SameSite,
+				})
+			}
+			r = r.WithContext(context.WithValue(r.Context(), csrfTokenKey{}, maskCSRFToken(token)))
+
+			if isSafeMethod(r.Method) || config.exempt(r) {
+				next(w, r)
+				return
+			}
+
+			if err := config.verify(r, token, ok); err != nil {
+				LogError(r, err)
+				config.ErrorHandler(w, r, err)
+				return
+			}
+			next(w, r)
+		}
+	}
+}
+
+// CSRFTokenFrom returns a masked token for rendering into forms or meta tags.
+// It differs on every request so it is safe to embed in compressed pages.
+func CSRFTokenFrom(ctx context.Context) string {
+	token, _ := ctx.Value(csrfTokenKey{}).(string)
+	return token
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+func (c CSRFConfig) exempt(r *http.Request) bool {
+	for _, prefix := range c.ExemptPaths {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			return true
+		}
+	}
+	return c.Exempt != nil && c.Exempt(r)
+}
+
+func (c CSRFConfig) verify(r *http.Request, cookieToken string, hadCookie bool) error {
+	if err := c.checkOrigin(r); err != nil {
+		return err
+	}
+	if !hadCookie {
+		return ErrCSRFTokenMissing
+	}
+
+	submitted := r.Header.Get(c.HeaderName)
+	if submitted == "" {
+		submitted = r.PostFormValue(c.FormField)
+	}
+	if submitted == "" {
+		return ErrCSRFTokenMissing
+	}
+
+	unmasked, ok := unmaskCSRFToken(submitted)
+	if !ok || subtle.ConstantTimeCompare([]byte(unmasked), []byte(cookieToken)) != 1 {
+		return ErrCSRFTokenInvalid
+	}
+	return nil
+}
+
+func (c CSRFConfig) checkOrigin(r *http.Request) error {
+	origin := r.Header.Get("Origin")
+
+	switch r.Header.Get("Sec-Fetch-Site") {
+	case "", "same-origin", "none":
+	default:
+		if origin == "" || !c.trustedOrigin(origin) {
+			return ErrCSRFOriginMismatch
+		}
+	}
+
+	if origin == "" {
+		return nil
+	}
+	u, err := url.Parse(origin)
+	if err != nil {
+		return ErrCSRFOriginMismatch
+	}
+
+	// Behind a proxy that rewrites Host, compare against the host the
+	// client actually addressed as resolved by ProxyHeaders.
+	host := r.Host
+	if info, ok := ForwardedFrom(r.Context()); ok && info.Host != "" {
+		host = info.Host
+	}
+	if strings.EqualFold(u.Host, host) || c.trustedOrigin(origin) {
+		return nil
+	}
+	return ErrCSRFOriginMismatch
+}
+
+func (c CSRFConfig) trustedOrigin(origin string) bool {
+	for _, trusted := range c.TrustedOrigins {
+		if strings.EqualFold(origin, trusted) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c CSRFConfig) sessionID(r *http.Request) string {
+	if c.SessionID == nil {
+		return ""
+	}
+	return c.SessionID(r)
+}
+
+func (c CSRFConfig) newToken(session string) string {
+	raw := make([]byte, csrfTokenLength)
+	rand.Read(raw)
+	return base64.RawURLEncoding.EncodeToString(raw) + "." + c.sign(raw, session)
+}
+
+// sign covers the random token and the session it was issued to; the token
+// has a fixed length so the concatenation is unambiguous.
+func (c CSRFConfig) sign(raw []byte, session string) string {
+	mac := hmac.New(sha256.New, c.Secret)
+	mac.Write(raw)
+	mac.Write([]byte(session))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func (c CSRFConfig) cookieToken(r *http.Request, session string) (string, bool) {
+	cookie, err := r.Cookie(c.CookieName)
+	if err != nil {
+		return "", false
+	}
+	value, signature, ok := strings.Cut(cookie.Value, ".")
+	if !ok {
+		return "", false
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(raw) != csrfTokenLength {
+		return "", false
+	}
+	if !hmac.Equal([]byte(signature), []byte(c.sign(raw, session))) {
+		return "", false
+	}
+	return cookie.Value, true
+}
+
+// maskCSRFToken XORs the token with a random one-time pad so the rendered
+// value changes per request, defeating BREACH-style compression attacks.
+func maskCSRFToken(token string) string {
+	pad := make([]byte, len(token))
+	rand.Read(pad)
+	masked := make([]byte, 2*len(token))
+	copy(masked, pad)
+	for i := range token {
+		masked[len(token)+i] = token[i] ^ pad[i]
+	}
+	return base64.RawURLEncoding.EncodeToString(masked)
+}
+
+func unmaskCSRFToken(masked string) (string, bool) {
+	data, err := base64.RawURLEncoding.DecodeString(masked)
+	if err != nil || len(data) == 0 || len(data)%2 != 0 {
+		return "", false
+	}
+	n := len(data) / 2
+	token := make([]byte, n)
+	for i := 0; i < n; i++ {
+		token[i] = data[i] ^ data[n+i]
+	}
+	return string(token), true
+}
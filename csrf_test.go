@@ -0,0 +1,189 @@
+package simplerouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func newCSRFRouter() *Router {
+	router := New().Use(CSRF(CSRFConfig{
+		Secret:         []byte("test-secret"),
+		ExemptPaths:    []string{"/webhooks"},
+		TrustedOrigins: []string{"https://admin.example.com"},
+	}))
+
+	router.GET("/form", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(CSRFTokenFrom(r.Context())))
+	})
+	router.POST("/form", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("saved"))
+	})
+	router.Group("/webhooks").POST("/stripe", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hook"))
+	})
+	return router
+}
+
+func fetchCSRFToken(t *testing.T, router *Router) (*http.Cookie, string) {
+	t.Helper()
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "http://example.com/form", nil))
+
+	cookies := rr.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("Expected CSRF cookie to be set, got %v", cookies)
+	}
+	if !cookies[0].HttpOnly || cookies[0].SameSite != http.SameSiteLaxMode {
+		t.Errorf("Expected HttpOnly SameSite=Lax cookie, got %+v", cookies[0])
+	}
+	return cookies[0], rr.Body.String()
+}
+
+func TestCSRF(t *testing.T) {
+	router := newCSRFRouter()
+	cookie, token := fetchCSRFToken(t, router)
+	_, otherToken := fetchCSRFToken(t, router)
+
+	tests := []struct {
+		name         string
+		path         string
+		cookie       *http.Cookie
+		header       string
+		form         string
+		origin       string
+		fetchSite    string
+		expectStatus int
+	}{
+		{"valid header token", "/form", cookie, token, "", "", "", http.StatusOK},
+		{"valid form token", "/form", cookie, "", token, "", "", http.StatusOK},
+		{"same origin", "/form", cookie, token, "", "http://example.com", "same-origin", http.StatusOK},
+		{"trusted origin", "/form", cookie, token, "", "https://admin.example.com", "same-site", http.StatusOK},
+		{"missing token", "/form", cookie, "", "", "", "", http.StatusForbidden},
+		{"missing cookie", "/form", nil, token, "", "", "", http.StatusForbidden},
+		{"token from another cookie", "/form", cookie, otherToken, "", "", "", http.StatusForbidden},
+		{"forged cookie", "/form", &http.Cookie{Name: "csrf_token", Value: "abc.def"}, token, "", "", "", http.StatusForbidden},
+		{"cross site", "/form", cookie, token, "", "https://evil.test", "cross-site", http.StatusForbidden},
+		{"foreign origin without fetch metadata", "/form", cookie, token, "", "https://evil.test", "", http.StatusForbidden},
+		{"exempt group", "/webhooks/stripe", nil, "", "", "https://stripe.test", "cross-site", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req *http.Request
+			if tt.form != "" {
+				req = httptest.NewRequest("POST", "http://example.com"+tt.path, strings.NewReader(url.Values{"csrf_token": {tt.form}}.Encode()))
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			} else {
+				req = httptest.NewRequest("POST", "http://example.com"+tt.path, nil)
+			}
+			if tt.cookie != nil {
+				req.AddCookie(tt.cookie)
+			}
+			if tt.header != "" {
+				req.Header.Set("X-CSRF-Token", tt.header)
+			}
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.fetchSite != "" {
+				req.Header.Set("Sec-Fetch-Site", tt.fetchSite)
+			}
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.expectStatus {
+				t.Errorf("Expected status %d, got %d (%s)", tt.expectStatus, rr.Code, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestCSRFTokenMasking(t *testing.T) {
+	router := newCSRFRouter()
+	cookie, _ := fetchCSRFToken(t, router)
+
+	var tokens []string
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest("GET", "http://example.com/form", nil)
+		req.AddCookie(cookie)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if len(rr.Result().Cookies()) != 0 {
+			t.Error("Expected existing valid cookie to be reused")
+		}
+		tokens = append(tokens, rr.Body.String())
+	}
+
+	if tokens[0] == tokens[1] {
+		t.Error("Expected masked tokens to differ per request")
+	}
+	for _, token := range tokens {
+		if unmasked, ok := unmaskCSRFToken(token); !ok || unmasked != cookie.Value {
+			t.Errorf("Expected masked token to unmask to the cookie value")
+		}
+	}
+}
+
+func TestCSRFSessionBinding(t *testing.T) {
+	config := CSRFConfig{
+		Secret:    []byte("test-secret"),
+		SessionID: func(r *http.Request) string { return r.Header.Get("X-Session") },
+	}
+	router := New().Use(CSRF(config))
+	router.GET("/form", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(CSRFTokenFrom(r.Context())))
+	})
+	router.POST("/form", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("saved"))
+	})
+
+	// An attacker obtains a validly signed cookie and token for their own
+	// session and plants them on the victim.
+	req := httptest.NewRequest("GET", "http://example.com/form", nil)
+	req.Header.Set("X-Session", "attacker")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	planted, token := rr.Result().Cookies()[0], rr.Body.String()
+
+	for session, expected := range map[string]int{"attacker": http.StatusOK, "victim": http.StatusForbidden} {
+		req := httptest.NewRequest("POST", "http://example.com/form", nil)
+		req.Header.Set("X-Session", session)
+		req.Header.Set("X-CSRF-Token", token)
+		req.AddCookie(planted)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != expected {
+			t.Errorf("session %s: expected status %d, got %d", session, expected, rr.Code)
+		}
+	}
+}
+
+func TestCSRFForwardedHost(t *testing.T) {
+	router := New().Use(RealIP("10.0.0.0/8"), CSRF(CSRFConfig{Secret: []byte("test-secret")}))
+	router.GET("/form", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(CSRFTokenFrom(r.Context())))
+	})
+	router.POST("/form", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("saved"))
+	})
+	cookie, token := fetchCSRFToken(t, router)
+
+	req := httptest.NewRequest("POST", "http://app-internal:8080/form", nil)
+	req.RemoteAddr = "10.0.0.1:4000"
+	req.Header.Set("X-Forwarded-For", "198.51.100.7")
+	req.Header.Set("X-Forwarded-Host", "shop.example")
+	req.Header.Set("Origin", "https://shop.example")
+	req.Header.Set("X-CSRF-Token", token)
+	req.AddCookie(cookie)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected origin to match the forwarded host, got %d (%s)", rr.Code, rr.Body.String())
+	}
+}
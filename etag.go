@@ -0,0 +1,218 @@
+package simplerouter
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type ETagConfig struct {
+	// Weak marks generated ETags as weak (W/"..."), for responses that are
+	// semantically but not byte-for-byte equivalent, such as those later
+	// compressed by another middleware.
+	Weak bool
+
+	// MaxBufferSize caps how much of a response is buffered to compute its
+	// ETag; larger responses are streamed without one. Defaults to 1 MiB.
+	MaxBufferSize int
+
+	// Current reports the ETag and Last-Modified time of the resource an
+	// unsafe request targets, for evaluating If-Match, If-None-Match and
+	// If-Unmodified-Since. It must produce the same ETags as the resource's
+	// GET responses. When nil, preconditions on unsafe methods are left to
+	// the handler.
+	Current func(r *http.Request) (etag string, lastModified time.Time, exists bool)
+}
+
+// ETag buffers successful GET and HEAD responses, sets an ETag from a hash
+// of the body unless the handler set one, and answers conditional requests
+// with 304 Not Modified or 412 Precondition Failed. Preconditions on unsafe
+// methods are checked against config.Current before the handler runs.
+func ETag(config ETagConfig) Middleware {
+	if config.MaxBufferSize <= 0 {
+		config.MaxBufferSize = 1 << 20
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				if config.Current != nil && hasPreconditions(r) {
+					etag, lastModified, exists := config.Current(r)
+					if evaluatePreconditions(r, etag, lastModified, exists) != 0 {
+						http.Error(w, http.StatusText(http.StatusPreconditionFailed), http.StatusPreconditionFailed)
+						return
+					}
+				}
+				next(w, r)
+				return
+			}
+
+			ew := &etagWriter{w: w, header: make(http.Header), limit: config.MaxBufferSize}
+			next(ew, r)
+			if ew.committed {
+				return
+			}
+
+			ew.WriteHeader(http.StatusOK)
+			if ew.status != http.StatusOK {
+				ew.commit()
+				return
+			}
+
+			etag := ew.header.Get("ETag")
+			if etag == "" {
+				etag = config.generate(ew.buf.Bytes())
+				ew.header.Set("ETag", etag)
+			}
+			lastModified, _ := http.ParseTime(ew.header.Get("Last-Modified"))
+
+			switch evaluatePreconditions(r, etag, lastModified, true) {
+			case http.StatusNotModified:
+				for _, name := range []string{"Content-Type", "Content-Length", "Content-Encoding", "Transfer-Encoding"} {
+					ew.header.Del(name)
+				}
+				ew.status = http.StatusNotModified
+				ew.buf.Reset()
+				ew.commit()
+			case http.StatusPreconditionFailed:
+				http.Error(w, http.StatusText(http.StatusPreconditionFailed), http.StatusPreconditionFailed)
+			default:
+				ew.commit()
+			}
+		}
+	}
+}
+
+func (c ETagConfig) generate(body []byte) string {
+	sum := sha256.Sum256(body)
+	etag := `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+	if c.Weak {
+		return "W/" + etag
+	}
+	return etag
+}
+
+func hasPreconditions(r *http.Request) bool {
+	return r.Header.Get("If-Match") != "" || r.Header.Get("If-Unmodified-Since") != "" ||
+		r.Header.Get("If-None-Match") != ""
+}
+
+// evaluatePreconditions applies RFC 9110 section 13.2.2 and returns 304, 412
+// or 0 when the request should proceed.
+func evaluatePreconditions(r *http.Request, etag string, lastModified time.Time, exists bool) int {
+	safe := r.Method == http.MethodGet || r.Method == http.MethodHead
+
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" {
+		if !exists || !etagListMatches(ifMatch, etag, true) {
+			return http.StatusPreconditionFailed
+		}
+	} else if since, err := http.ParseTime(r.Header.Get("If-Unmodified-Since")); err == nil && exists && !lastModified.IsZero() {
+		if lastModified.Truncate(time.Second).After(since) {
+			return http.StatusPreconditionFailed
+		}
+	}
+
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		if exists && etagListMatches(ifNoneMatch, etag, false) {
+			if safe {
+				return http.StatusNotModified
+			}
+			return http.StatusPreconditionFailed
+		}
+	} else if since, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && safe && exists && !lastModified.IsZero() {
+		if !lastModified.Truncate(time.Second).After(since) {
+			return http.StatusNotModified
+		}
+	}
+	return 0
+}
+
+// etagListMatches reports whether etag appears in the header's list, using
+// strong comparison (both strong and identical) or weak comparison (opaque
+// tags identical).
+func etagListMatches(list, etag string, strong bool) bool {
+	if strings.TrimSpace(list) == "*" {
+		return true
+	}
+	if etag == "" || (strong && strings.HasPrefix(etag, "W/")) {
+		return false
+	}
+	opaque := strings.TrimPrefix(etag, "W/")
+
+	for _, candidate := range strings.Split(list, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if strong && strings.HasPrefix(candidate, "W/") {
+			continue
+		}
+		if strings.TrimPrefix(candidate, "W/") == opaque {
+			return true
+		}
+	}
+	return false
+}
+
+type etagWriter struct {
+	w           http.ResponseWriter
+	header      http.Header
+	buf         bytes.Buffer
+	status      int
+	limit       int
+	wroteHeader bool
+	committed   bool
+}
+
+func (ew *etagWriter) Header() http.Header {
+	return ew.header
+}
+
+func (ew *etagWriter) WriteHeader(status int) {
+	if ew.wroteHeader || ew.committed {
+		return
+	}
+	ew.wroteHeader = true
+	ew.status = status
+}
+
+func (ew *etagWriter) Write(b []byte) (int, error) {
+	ew.WriteHeader(http.StatusOK)
+	if ew.committed {
+		return ew.w.Write(b)
+	}
+	if ew.buf.Len()+len(b) > ew.limit {
+		ew.commit()
+		return ew.w.Write(b)
+	}
+	return ew.buf.Write(b)
+}
+
+// Flush commits the buffered response without an ETag so streaming
+// handlers keep working.
+func (ew *etagWriter) Flush() {
+	if !ew.committed {
+		ew.WriteHeader(http.StatusOK)
+		ew.commit()
+	}
+	if flusher, ok := ew.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (ew *etagWriter) commit() {
+	dst := ew.w.Header()
+	for key, values := range ew.header {
+		dst[key] = values
+	}
+	status := ew.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	ew.w.WriteHeader(status)
+	if ew.buf.Len() > 0 {
+		ew.w.Write(ew.buf.Bytes())
+	}
+	ew.buf.Reset()
+	ew.committed = true
+}
@@ -0,0 +1,201 @@
+package simplerouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func etagRequest(router *Router, method, path string, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestETagGenerated(t *testing.T) {
+	router := New().Use(ETag(ETagConfig{}))
+	router.GET("/poll", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"status":"idle"}`))
+	})
+	router.GET("/missing", func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+
+	first := etagRequest(router, "GET", "/poll", nil)
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || !strings.HasPrefix(etag, `"`) || first.Body.String() != `{"status":"idle"}` {
+		t.Fatalf("Expected 200 with strong ETag, got %d %q %q", first.Code, etag, first.Body.String())
+	}
+
+	if again := etagRequest(router, "GET", "/poll", nil); again.Header().Get("ETag") != etag {
+		t.Errorf("Expected stable ETag, got %q and %q", etag, again.Header().Get("ETag"))
+	}
+
+	notModified := etagRequest(router, "GET", "/poll", map[string]string{"If-None-Match": `"other", ` + etag})
+	if notModified.Code != http.StatusNotModified || notModified.Body.Len() != 0 {
+		t.Errorf("Expected 304 with empty body, got %d %q", notModified.Code, notModified.Body.String())
+	}
+	if notModified.Header().Get("ETag") != etag || notModified.Header().Get("Content-Type") != "" {
+		t.Errorf("Expected 304 to keep ETag and drop Content-Type, got %v", notModified.Header())
+	}
+
+	if weak := etagRequest(router, "GET", "/poll", map[string]string{"If-None-Match": "W/" + etag}); weak.Code != http.StatusNotModified {
+		t.Errorf("Expected weak comparison for If-None-Match, got %d", weak.Code)
+	}
+	if changed := etagRequest(router, "GET", "/poll", map[string]string{"If-None-Match": `"stale"`}); changed.Code != http.StatusOK {
+		t.Errorf("Expected 200 for non-matching ETag, got %d", changed.Code)
+	}
+	if missing := etagRequest(router, "GET", "/missing", nil); missing.Code != http.StatusNotFound || missing.Header().Get("ETag") != "" {
+		t.Errorf("Expected 404 without ETag, got %d %q", missing.Code, missing.Header().Get("ETag"))
+	}
+}
+
+func TestETagWeakAndHandlerSet(t *testing.T) {
+	router := New()
+	router.With(ETag(ETagConfig{Weak: true})).GET("/weak", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("body"))
+	})
+	router.With(ETag(ETagConfig{})).GET("/versioned", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", `"v42"`)
+		w.Write([]byte("body"))
+	})
+
+	if rr := etagRequest(router, "GET", "/weak", nil); !strings.HasPrefix(rr.Header().Get("ETag"), `W/"`) {
+		t.Errorf("Expected weak ETag, got %q", rr.Header().Get("ETag"))
+	}
+	if rr := etagRequest(router, "GET", "/versioned", map[string]string{"If-None-Match": `"v42"`}); rr.Code != http.StatusNotModified {
+		t.Errorf("Expected handler-set ETag to be honoured, got %d", rr.Code)
+	}
+}
+
+func TestETagLastModified(t *testing.T) {
+	modified := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	router := New().Use(ETag(ETagConfig{}))
+	router.GET("/report", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+		w.Write([]byte("report"))
+	})
+
+	tests := []struct {
+		name    string
+		headers map[string]string
+		status  int
+	}{
+		{"not modified since", map[string]string{"If-Modified-Since": modified.Format(http.TimeFormat)}, http.StatusNotModified},
+		{"modified since", map[string]string{"If-Modified-Since": modified.Add(-time.Hour).Format(http.TimeFormat)}, http.StatusOK},
+		{"if-none-match takes precedence", map[string]string{
+			"If-None-Match":     `"stale"`,
+			"If-Modified-Since": modified.Format(http.TimeFormat),
+		}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if rr := etagRequest(router, "GET", "/report", tt.headers); rr.Code != tt.status {
+				t.Errorf("Expected status %d, got %d", tt.status, rr.Code)
+			}
+		})
+	}
+}
+
+func TestETagUnsafePreconditions(t *testing.T) {
+	modified := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	document := "v1"
+	config := ETagConfig{}
+	config.Current = func(r *http.Request) (string, time.Time, bool) {
+		if r.PathValue("id") != "1" {
+			return "", time.Time{}, false
+		}
+		return config.generate([]byte(document)), modified, true
+	}
+	router := New().Use(ETag(config))
+	router.GET("/docs/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("id") != "1" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Last-Modified", modified.Format(http.TimeFormat))
+		w.Write([]byte(document))
+	})
+	router.PUT("/docs/{id}", func(w http.ResponseWriter, r *http.Request) {
+		document = "v2"
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	etag := etagRequest(router, "GET", "/docs/1", nil).Header().Get("ETag")
+
+	tests := []struct {
+		name    string
+		path    string
+		headers map[string]string
+		status  int
+	}{
+		{"stale if-match", "/docs/1", map[string]string{"If-Match": `"stale"`}, http.StatusPreconditionFailed},
+		{"weak if-match never matches", "/docs/1", map[string]string{"If-Match": "W/" + etag}, http.StatusPreconditionFailed},
+		{"modified after if-unmodified-since", "/docs/1", map[string]string{"If-Unmodified-Since": modified.Add(-time.Hour).Format(http.TimeFormat)}, http.StatusPreconditionFailed},
+		{"create-only on existing resource", "/docs/1", map[string]string{"If-None-Match": "*"}, http.StatusPreconditionFailed},
+		{"create-only on new resource", "/docs/2", map[string]string{"If-None-Match": "*"}, http.StatusNoContent},
+		{"if-match on missing resource", "/docs/2", map[string]string{"If-Match": "*"}, http.StatusPreconditionFailed},
+		{"current if-match", "/docs/1", map[string]string{"If-Match": etag}, http.StatusNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			document = "v1"
+			rr := etagRequest(router, "PUT", tt.path, tt.headers)
+			if rr.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d", tt.status, rr.Code)
+			}
+			if tt.status == http.StatusPreconditionFailed && document != "v1" {
+				t.Error("Expected handler not to run when precondition fails")
+			}
+		})
+	}
+}
+
+func TestETagUnsafeWithoutCurrent(t *testing.T) {
+	router := New().Use(ETag(ETagConfig{}))
+	router.GET("/docs", func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Expected GET handler not to run for an unsafe request")
+	})
+	router.PUT("/docs", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	rr := etagRequest(router, "PUT", "/docs", map[string]string{"If-Match": `"stale"`})
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("Expected preconditions to be left to the handler, got %d", rr.Code)
+	}
+}
+
+func TestETagStreamsLargeResponses(t *testing.T) {
+	router := New().Use(ETag(ETagConfig{MaxBufferSize: 8}))
+	router.GET("/large", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("0123456789"))
+	})
+	router.GET("/stream", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("a"))
+		w.(http.Flusher).Flush()
+		w.Write([]byte("b"))
+	})
+
+	for _, path := range []string{"/large", "/stream"} {
+		rr := etagRequest(router, "GET", path, nil)
+		if rr.Header().Get("ETag") != "" {
+			t.Errorf("%s: expected no ETag for streamed response, got %q", path, rr.Header().Get("ETag"))
+		}
+		if path == "/large" && rr.Body.String() != "0123456789" {
+			t.Errorf("Expected full body, got %q", rr.Body.String())
+		}
+		if path == "/stream" && (rr.Body.String() != "ab" || !rr.Flushed) {
+			t.Errorf("Expected flushed streaming body, got %q flushed=%v", rr.Body.String(), rr.Flushed)
+		}
+	}
+}
@@ -39,29 +39,13 @@ func main() {
 		}
 	}
 
-	authMiddleware := func(next simplerouter.HandlerFunc) simplerouter.HandlerFunc {
-		return func(w http.ResponseWriter, r *http.Request) {
-			token := r.Header.Get("Authorization")
-			if token != "Bearer valid-token" {
-				http.Error(w, "Unauthorized", http.StatusUnauthorized)
-				return
-			}
-			fmt.Printf("AUTH: User authenticated for %s\n", r.URL.Path)
-			next(w, r)
-		}
-	}
-
-	adminMiddleware := func(next simplerouter.HandlerFunc) simplerouter.HandlerFunc {
-		return func(w http.ResponseWriter, r *http.Request) {
-			role := r.Header.Get("X-User-Role")
-			if role != "admin" {
-				http.Error(w, "Forbidden: Admin access required", http.StatusForbidden)
-				return
-			}
-			fmt.Printf("ADMIN: Admin access granted for %s\n", r.URL.Path)
-			next(w, r)
-		}
-	}
+	authMiddleware := simplerouter.APIKeyAuth(simplerouter.APIKeyConfig{
+		AllowBearer: true,
+		Validator: simplerouter.StaticAPIKeys(map[string]*simplerouter.Principal{
+			"valid-token": {ID: "demo-user"},
+			"admin-token": {ID: "demo-admin", Roles: []string{"admin"}},
+		}),
+	})
 
 	// Routes with different logging setups
 
@@ -101,7 +85,8 @@ func main() {
 
 	// Route builder with access logging
 	routerWithDefaults.Route("/api/admin").
-		Use(authMiddleware, adminMiddleware).
+		Use(authMiddleware).
+		Require("role:admin").
 		GET(func(w http.ResponseWriter, r *http.Request) {
 			fmt.Fprintf(w, "Admin API endpoint (with access logging)")
 		})
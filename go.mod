@@ -1,3 +1,5 @@
 module github.com/tech-arch1tect/simplerouter
 
 go 1.24.4
+
+require golang.org/x/crypto v0.45.0
@@ -0,0 +1,161 @@
+package simplerouter
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+	"sync/atomic"
+)
+
+var ErrIPDenied = errors.New("simplerouter: client IP not permitted")
+
+type IPFilterConfig struct {
+	// Allow and Deny hold CIDR prefixes or single addresses, IPv4 or IPv6.
+	// Deny takes precedence; when Allow is non-empty only matching clients
+	// are admitted.
+	Allow []string
+	Deny  []string
+
+	// TrustedProxies lists the prefixes whose X-Forwarded-For entries are
+	// believed when resolving the client address. It is ignored when
+	// ProxyHeaders has already resolved the client.
+	TrustedProxies []string
+
+	Body        string
+	ContentType string
+}
+
+type IPFilter struct {
+	rules       atomic.Pointer[ipRules]
+	trusted     []netip.Prefix
+	body        string
+	contentType string
+}
+
+type ipRules struct {
+	allow []netip.Prefix
+	deny  []netip.Prefix
+}
+
+func NewIPFilter(config IPFilterConfig) (*IPFilter, error) {
+	trusted, err := ParsePrefixes(config.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	if config.Body == "" {
+		config.Body = http.StatusText(http.StatusForbidden)
+	}
+	if config.ContentType == "" {
+		config.ContentType = "text/plain; charset=utf-8"
+	}
+
+	f := &IPFilter{
+		trusted:     trusted,
+		body:        config.Body,
+		contentType: config.ContentType,
+	}
+	if err := f.Update(config.Allow, config.Deny); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Update atomically replaces the allow and deny lists. Requests in flight
+// finish against the lists they started with. On error the current lists
+// are kept.
+func (f *IPFilter) Update(allow, deny []string) error {
+	allowPrefixes, err := ParsePrefixes(allow)
+	if err != nil {
+		return err
+	}
+	denyPrefixes, err := ParsePrefixes(deny)
+	if err != nil {
+		return err
+	}
+	f.rules.Store(&ipRules{allow: allowPrefixes, deny: denyPrefixes})
+	return nil
+}
+
+func (f *IPFilter) Allowed(addr netip.Addr) bool {
+	rules := f.rules.Load()
+	addr = addr.Unmap()
+
+	if containsAddr(rules.deny, addr) {
+		return false
+	}
+	return len(rules.allow) == 0 || containsAddr(rules.allow, addr)
+}
+
+func (f *IPFilter) Middleware() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if !f.Allowed(clientAddr(r, f.trusted)) {
+				LogError(r, ErrIPDenied)
+				w.Header().Set("Content-Type", f.contentType)
+				w.Header().Set("X-Content-Type-Options", "nosniff")
+				w.WriteHeader(http.StatusForbidden)
+				fmt.Fprint(w, f.body)
+				return
+			}
+			next(w, r)
+		}
+	}
+}
+
+// ParsePrefixes parses CIDR prefixes, treating bare addresses as single-host
+// prefixes.
+func ParsePrefixes(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if strings.Contains(value, "/") {
+			prefix, err := netip.ParsePrefix(value)
+			if err != nil {
+				return nil, fmt.Errorf("simplerouter: invalid CIDR %q: %w", value, err)
+			}
+			if prefix.Addr().Is4In6() {
+				prefix = netip.PrefixFrom(prefix.Addr().Unmap(), prefix.Bits()-96)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+
+		addr, err := netip.ParseAddr(value)
+		if err != nil {
+			return nil, fmt.Errorf("simplerouter: invalid IP %q: %w", value, err)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	if !addr.IsValid() {
+		return false
+	}
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientAddr prefers the address resolved by ProxyHeaders and otherwise
+// applies the filter's own trusted proxies to X-Forwarded-For.
+func clientAddr(r *http.Request, trusted []netip.Prefix) netip.Addr {
+	if info, ok := ForwardedFrom(r.Context()); ok {
+		return info.ClientIP
+	}
+
+	addr := parseRemoteAddr(r.RemoteAddr)
+	if !containsAddr(trusted, addr) {
+		return addr
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	addr, _ = walkForwardedFor(hops, addr, trusted, parseForwardedNode)
+	return addr
+}
@@ -0,0 +1,115 @@
+package simplerouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"sync"
+	"testing"
+)
+
+func TestIPFilter(t *testing.T) {
+	filter, err := NewIPFilter(IPFilterConfig{
+		Allow:          []string{"10.0.0.0/8", "2001:db8::/32", "192.0.2.7"},
+		Deny:           []string{"10.66.0.0/16"},
+		TrustedProxies: []string{"172.16.0.0/12"},
+		Body:           `{"error":"forbidden"}`,
+		ContentType:    "application/json",
+	})
+	if err != nil {
+		t.Fatalf("NewIPFilter: %v", err)
+	}
+
+	router := New()
+	router.Group("/admin").Use(filter.Middleware()).GET("/", okHandler)
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		status     int
+	}{
+		{"allowed IPv4", "10.1.2.3:5000", "", http.StatusOK},
+		{"denied within allowed range", "10.66.1.1:5000", "", http.StatusForbidden},
+		{"outside allowlist", "203.0.113.9:5000", "", http.StatusForbidden},
+		{"single address", "192.0.2.7:5000", "", http.StatusOK},
+		{"allowed IPv6", "[2001:db8::1]:5000", "", http.StatusOK},
+		{"IPv4-mapped IPv6", "[::ffff:10.1.2.3]:5000", "", http.StatusOK},
+		{"trusted proxy forwards allowed client", "172.16.0.1:80", "10.1.2.3", http.StatusOK},
+		{"trusted proxy chain", "172.16.0.1:80", "203.0.113.9, 10.1.2.3, 172.16.0.2", http.StatusOK},
+		{"spoofed header via trusted proxy", "172.16.0.1:80", "10.1.2.3, 203.0.113.9", http.StatusForbidden},
+		{"untrusted peer header ignored", "203.0.113.9:5000", "10.1.2.3", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/admin/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d", tt.status, rr.Code)
+			}
+			if tt.status == http.StatusForbidden {
+				if rr.Body.String() != `{"error":"forbidden"}` || rr.Header().Get("Content-Type") != "application/json" {
+					t.Errorf("Expected configured body, got %q (%s)", rr.Body.String(), rr.Header().Get("Content-Type"))
+				}
+			}
+		})
+	}
+}
+
+func TestIPFilterUpdate(t *testing.T) {
+	filter, err := NewIPFilter(IPFilterConfig{Allow: []string{"10.0.0.0/8"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	office := netip.MustParseAddr("198.51.100.20")
+
+	if filter.Allowed(office) {
+		t.Fatal("Expected office IP to be denied before update")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 1000; j++ {
+				filter.Allowed(office)
+			}
+		}()
+	}
+	if err := filter.Update([]string{"10.0.0.0/8", "198.51.100.0/24"}, nil); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	wg.Wait()
+
+	if !filter.Allowed(office) {
+		t.Error("Expected office IP to be allowed after update")
+	}
+
+	if err := filter.Update([]string{"not-a-cidr"}, nil); err == nil {
+		t.Error("Expected invalid CIDR to be rejected")
+	}
+	if !filter.Allowed(office) {
+		t.Error("Expected failed update to keep previous rules")
+	}
+}
+
+func TestIPFilterDenyOnly(t *testing.T) {
+	filter, err := NewIPFilter(IPFilterConfig{Deny: []string{"2001:db8:bad::/48"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filter.Allowed(netip.MustParseAddr("2001:db8:bad::1")) {
+		t.Error("Expected denied prefix to be blocked")
+	}
+	if !filter.Allowed(netip.MustParseAddr("2001:db8:900d::1")) {
+		t.Error("Expected other addresses to be allowed without an allowlist")
+	}
+}
@@ -0,0 +1,499 @@
+package simplerouter
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrJWTMissing     = errors.New("simplerouter: missing bearer token")
+	ErrJWTMalformed   = errors.New("simplerouter: malformed token")
+	ErrJWTUnknownKey  = errors.New("simplerouter: token signed with unknown key")
+	ErrJWTAlgorithm   = errors.New("simplerouter: token algorithm not allowed")
+	ErrJWTSignature   = errors.New("simplerouter: token signature invalid")
+	ErrJWTExpired     = errors.New("simplerouter: token expired")
+	ErrJWTNotYetValid = errors.New("simplerouter: token not yet valid")
+	ErrJWTIssuer      = errors.New("simplerouter: token issuer not accepted")
+	ErrJWTAudience    = errors.New("simplerouter: token audience not accepted")
+)
+
+const (
+	JWTAlgHS256 = "HS256"
+	JWTAlgRS256 = "RS256"
+	JWTAlgES256 = "ES256"
+	JWTAlgEdDSA = "EdDSA"
+)
+
+// JWK is a single verification key. Key holds a []byte for HS256,
+// *rsa.PublicKey for RS256, *ecdsa.PublicKey for ES256 and
+// ed25519.PublicKey for EdDSA.
+type JWK struct {
+	ID        string
+	Algorithm string
+	Key       any
+}
+
+// JWKS is a set of keys indexed by kid. It is safe for concurrent use and
+// can be replaced at runtime with Reload or SetKeys to rotate keys.
+type JWKS struct {
+	path string
+	mu   sync.RWMutex
+	keys map[string]JWK
+}
+
+func NewJWKS(keys ...JWK) (*JWKS, error) {
+	set := &JWKS{}
+	if err := set.SetKeys(keys...); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+// LoadJWKSFile reads an RFC 7517 key set from path. Call Reload to pick up
+// a rotated file.
+func LoadJWKSFile(path string) (*JWKS, error) {
+	set := &JWKS{path: path}
+	if err := set.Reload(); err != nil {
+		return nil, err
+	}
+	return set, nil
+}
+
+func (s *JWKS) Reload() error {
+	if s.path == "" {
+		return errors.New("simplerouter: JWKS was not loaded from a file")
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return err
+	}
+	keys, err := ParseJWKS(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", s.path, err)
+	}
+	return s.SetKeys(keys...)
+}
+
+func (s *JWKS) SetKeys(keys ...JWK) error {
+	indexed := make(map[string]JWK, len(keys))
+	for _, key := range keys {
+		if err := checkJWKType(key); err != nil {
+			return err
+		}
+		if _, exists := indexed[key.ID]; exists {
+			return fmt.Errorf("simplerouter: duplicate key id %q", key.ID)
+		}
+		indexed[key.ID] = key
+	}
+
+	s.mu.Lock()
+	s.keys = indexed
+	s.mu.Unlock()
+	return nil
+}
+
+// lookup finds the key for kid. Tokens without a kid are accepted only when
+// the set holds exactly one key.
+func (s *JWKS) lookup(kid string) (JWK, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if kid == "" && len(s.keys) == 1 {
+		for _, key := range s.keys {
+			return key, true
+		}
+	}
+	key, ok := s.keys[kid]
+	return key, ok
+}
+
+func checkJWKType(key JWK) error {
+	var ok bool
+	switch key.Algorithm {
+	case JWTAlgHS256:
+		_, ok = key.Key.([]byte)
+	case JWTAlgRS256:
+		_, ok = key.Key.(*rsa.PublicKey)
+	case JWTAlgES256:
+		var pub *ecdsa.PublicKey
+		pub, ok = key.Key.(*ecdsa.PublicKey)
+		ok = ok && pub.Curve == elliptic.P256()
+	case JWTAlgEdDSA:
+		_, ok = key.Key.(ed25519.PublicKey)
+	default:
+		return fmt.Errorf("simplerouter: unsupported JWT algorithm %q", key.Algorithm)
+	}
+	if !ok {
+		return fmt.Errorf("simplerouter: key %q does not match algorithm %s", key.ID, key.Algorithm)
+	}
+	return nil
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	Crv string `json:"crv"`
+	K   string `json:"k"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// ParseJWKS decodes an RFC 7517 key set. Keys with use other than "sig" are
+// skipped.
+func ParseJWKS(data []byte) ([]JWK, error) {
+	var doc struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+
+	keys := make([]JWK, 0, len(doc.Keys))
+	for _, raw := range doc.Keys {
+		if raw.Use != "" && raw.Use != "sig" {
+			continue
+		}
+		key, err := raw.toJWK()
+		if err != nil {
+			return nil, fmt.Errorf("key %q: %w", raw.Kid, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (k jsonWebKey) toJWK() (JWK, error) {
+	decode := base64.RawURLEncoding.DecodeString
+	jwk := JWK{ID: k.Kid, Algorithm: k.Alg}
+
+	switch k.Kty {
+	case "oct":
+		secret, err := decode(k.K)
+		if err != nil || len(secret) == 0 {
+			return jwk, errors.New("invalid symmetric key")
+		}
+		jwk.Key = secret
+		jwk.Algorithm = cmp.Or(k.Alg, JWTAlgHS256)
+	case "RSA":
+		n, errN := decode(k.N)
+		e, errE := decode(k.E)
+		if errN != nil || errE != nil || len(n) == 0 || len(e) == 0 || len(e) > 4 {
+			return jwk, errors.New("invalid RSA key")
+		}
+		jwk.Key = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		jwk.Algorithm = cmp.Or(k.Alg, JWTAlgRS256)
+	case "EC":
+		if k.Crv != "P-256" {
+			return jwk, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, errX := decode(k.X)
+		y, errY := decode(k.Y)
+		if errX != nil || errY != nil || len(x) != 32 || len(y) != 32 {
+			return jwk, errors.New("invalid EC key")
+		}
+		// Round-trip through crypto/ecdh to reject points not on the curve.
+		if _, err := ecdh.P256().NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+			return jwk, errors.New("invalid EC point")
+		}
+		jwk.Key = &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		jwk.Algorithm = cmp.Or(k.Alg, JWTAlgES256)
+	case "OKP":
+		x, err := decode(k.X)
+		if k.Crv != "Ed25519" || err != nil || len(x) != ed25519.PublicKeySize {
+			return jwk, errors.New("invalid Ed25519 key")
+		}
+		jwk.Key = ed25519.PublicKey(x)
+		jwk.Algorithm = cmp.Or(k.Alg, JWTAlgEdDSA)
+	default:
+		return jwk, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+	return jwk, checkJWKType(jwk)
+}
+
+// JWTClaims holds the registered claims of a verified token. Decode unmarshals
+// the full payload into an application-specific type.
+type JWTClaims struct {
+	Issuer    string
+	Subject   string
+	Audience  []string
+	ExpiresAt time.Time
+	NotBefore time.Time
+	IssuedAt  time.Time
+	ID        string
+	raw       json.RawMessage
+}
+
+func (c *JWTClaims) Decode(v any) error {
+	return json.Unmarshal(c.raw, v)
+}
+
+type jwtPayload struct {
+	Iss   string          `json:"iss"`
+	Sub   string          `json:"sub"`
+	Aud   json.RawMessage `json:"aud"`
+	Exp   *json.Number    `json:"exp"`
+	Nbf   *json.Number    `json:"nbf"`
+	Iat   *json.Number    `json:"iat"`
+	Jti   string          `json:"jti"`
+	Scope string          `json:"scope"`
+	Roles []string        `json:"roles"`
+}
+
+type jwtClaimsKey struct{}
+
+func JWTClaimsFrom(ctx context.Context) (*JWTClaims, bool) {
+	claims, ok := ctx.Value(jwtClaimsKey{}).(*JWTClaims)
+	return claims, ok
+}
+
+// JWTClaimsAs decodes the verified token payload of the request into T.
+func JWTClaimsAs[T any](ctx context.Context) (T, bool) {
+	var v T
+	claims, ok := JWTClaimsFrom(ctx)
+	if !ok || claims.Decode(&v) != nil {
+		return v, false
+	}
+	return v, true
+}
+
+type JWTConfig struct {
+	Keys       *JWKS
+	Algorithms []string
+	Issuers    []string
+	Audiences  []string
+	ClockSkew  time.Duration
+	// RequireExpiry rejects tokens without an exp claim.
+	RequireExpiry bool
+	ErrorHandler  func(w http.ResponseWriter, r *http.Request, err error)
+	now           func() time.Time
+}
+
+// JWT verifies bearer tokens against config.Keys and places the claims and a
+// Principal (sub, "scope" as permissions, "roles") into the request context.
+func JWT(config JWTConfig) Middleware {
+	if config.Keys == nil {
+		panic("simplerouter: JWT requires a key set")
+	}
+	if len(config.Algorithms) == 0 {
+		config.Algorithms = []string{JWTAlgHS256, JWTAlgRS256, JWTAlgES256, JWTAlgEdDSA}
+	}
+	if config.now == nil {
+		config.now = time.Now
+	}
+	if config.ErrorHandler == nil {
+		config.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+			challenge := `Bearer error="invalid_token"`
+			if errors.Is(err, ErrJWTMissing) {
+				challenge = "Bearer"
+			}
+			unauthorized(w, challenge)
+		}
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+			if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+				config.ErrorHandler(w, r, ErrJWTMissing)
+				return
+			}
+
+			claims, payload, err := config.verify(strings.TrimSpace(token))
+			if err != nil {
+				LogError(r, err)
+				config.ErrorHandler(w, r, err)
+				return
+			}
+
+			principal := &Principal{
+				ID:          claims.Subject,
+				Method:      "jwt",
+				Roles:       payload.Roles,
+				Permissions: strings.Fields(payload.Scope),
+			}
+			ctx := context.WithValue(r.Context(), jwtClaimsKey{}, claims)
+			next(w, r.WithContext(WithPrincipal(ctx, principal)))
+		}
+	}
+}
+
+func (c JWTConfig) verify(token string) (*JWTClaims, *jwtPayload, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, nil, ErrJWTMalformed
+	}
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, nil, ErrJWTMalformed
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil {
+		return nil, nil, ErrJWTMalformed
+	}
+	if !slices.Contains(c.Algorithms, header.Alg) {
+		return nil, nil, ErrJWTAlgorithm
+	}
+
+	key, ok := c.Keys.lookup(header.Kid)
+	if !ok {
+		return nil, nil, ErrJWTUnknownKey
+	}
+	// The key, not the token header, decides the algorithm, so an RSA public
+	// key can never be used as an HMAC secret.
+	if key.Algorithm != header.Alg {
+		return nil, nil, ErrJWTAlgorithm
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, nil, ErrJWTMalformed
+	}
+	if !verifyJWTSignature(key, []byte(parts[0]+"."+parts[1]), signature) {
+		return nil, nil, ErrJWTSignature
+	}
+
+	payloadJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, nil, ErrJWTMalformed
+	}
+	claims, payload, err := parseJWTClaims(payloadJSON)
+	if err != nil {
+		return nil, nil, err
+	}
+	return claims, payload, c.validate(claims)
+}
+
+func verifyJWTSignature(key JWK, signed, signature []byte) bool {
+	digest := sha256.Sum256(signed)
+
+	switch key.Algorithm {
+	case JWTAlgHS256:
+		mac := hmac.New(sha256.New, key.Key.([]byte))
+		mac.Write(signed)
+		return hmac.Equal(signature, mac.Sum(nil))
+	case JWTAlgRS256:
+		return rsa.VerifyPKCS1v15(key.Key.(*rsa.PublicKey), crypto.SHA256, digest[:], signature) == nil
+	case JWTAlgES256:
+		if len(signature) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		return ecdsa.Verify(key.Key.(*ecdsa.PublicKey), digest[:], r, s)
+	case JWTAlgEdDSA:
+		return ed25519.Verify(key.Key.(ed25519.PublicKey), signed, signature)
+	}
+	return false
+}
+
+func parseJWTClaims(data []byte) (*JWTClaims, *jwtPayload, error) {
+	var payload jwtPayload
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&payload); err != nil {
+		return nil, nil, ErrJWTMalformed
+	}
+
+	claims := &JWTClaims{
+		Issuer:  payload.Iss,
+		Subject: payload.Sub,
+		ID:      payload.Jti,
+		raw:     data,
+	}
+
+	if len(payload.Aud) > 0 {
+		var single string
+		if err := json.Unmarshal(payload.Aud, &single); err == nil {
+			claims.Audience = []string{single}
+		} else if err := json.Unmarshal(payload.Aud, &claims.Audience); err != nil {
+			return nil, nil, ErrJWTMalformed
+		}
+	}
+
+	for _, field := range []struct {
+		value *json.Number
+		dest  *time.Time
+	}{
+		{payload.Exp, &claims.ExpiresAt},
+		{payload.Nbf, &claims.NotBefore},
+		{payload.Iat, &claims.IssuedAt},
+	} {
+		if field.value == nil {
+			continue
+		}
+		date, err := parseNumericDate(*field.value)
+		if err != nil {
+			return nil, nil, err
+		}
+		*field.dest = date
+	}
+
+	return claims, &payload, nil
+}
+
+// maxNumericDate is 9999-12-31T23:59:59Z; later dates are rejected rather
+// than risk overflowing time arithmetic.
+const maxNumericDate = 253402300799
+
+func parseNumericDate(value json.Number) (time.Time, error) {
+	seconds, err := value.Float64()
+	if err != nil || seconds < 0 || seconds > maxNumericDate {
+		return time.Time{}, ErrJWTMalformed
+	}
+	whole := math.Floor(seconds)
+	return time.Unix(int64(whole), int64((seconds-whole)*float64(time.Second))), nil
+}
+
+func (c JWTConfig) validate(claims *JWTClaims) error {
+	now := c.now()
+
+	if claims.ExpiresAt.IsZero() {
+		if c.RequireExpiry {
+			return ErrJWTExpired
+		}
+	} else if !now.Before(claims.ExpiresAt.Add(c.ClockSkew)) {
+		return ErrJWTExpired
+	}
+	if !claims.NotBefore.IsZero() && now.Add(c.ClockSkew).Before(claims.NotBefore) {
+		return ErrJWTNotYetValid
+	}
+
+	if len(c.Issuers) > 0 && !slices.Contains(c.Issuers, claims.Issuer) {
+		return ErrJWTIssuer
+	}
+	if len(c.Audiences) > 0 && !slices.ContainsFunc(claims.Audience, func(aud string) bool {
+		return slices.Contains(c.Audiences, aud)
+	}) {
+		return ErrJWTAudience
+	}
+	return nil
+}
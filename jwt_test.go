@@ -0,0 +1,259 @@
+package simplerouter
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func signTestJWT(t *testing.T, alg, kid string, key any, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	var err error
+	switch alg {
+	case JWTAlgHS256:
+		mac := hmac.New(sha256.New, key.([]byte))
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	case JWTAlgRS256:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key.(*rsa.PrivateKey), crypto.SHA256, digest[:])
+	case JWTAlgES256:
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, key.(*ecdsa.PrivateKey), digest[:])
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	case JWTAlgEdDSA:
+		signature = ed25519.Sign(key.(ed25519.PrivateKey), []byte(signed))
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func jwtRequest(router *Router, path, token string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest("GET", path, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestJWTAlgorithms(t *testing.T) {
+	hmacKey := []byte("0123456789abcdef0123456789abcdef")
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	edPub, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	keys, err := NewJWKS(
+		JWK{ID: "hs", Algorithm: JWTAlgHS256, Key: hmacKey},
+		JWK{ID: "rs", Algorithm: JWTAlgRS256, Key: &rsaKey.PublicKey},
+		JWK{ID: "es", Algorithm: JWTAlgES256, Key: &ecKey.PublicKey},
+		JWK{ID: "ed", Algorithm: JWTAlgEdDSA, Key: edPub},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := New()
+	router.Group("/api").Use(JWT(JWTConfig{Keys: keys})).GET("/me", principalHandler)
+	router.Group("/public").GET("/info", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("public"))
+	})
+
+	claims := map[string]any{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()}
+	tests := []struct {
+		alg, kid string
+		key      any
+	}{
+		{JWTAlgHS256, "hs", hmacKey},
+		{JWTAlgRS256, "rs", rsaKey},
+		{JWTAlgES256, "es", ecKey},
+		{JWTAlgEdDSA, "ed", edKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.alg, func(t *testing.T) {
+			rr := jwtRequest(router, "/api/me", signTestJWT(t, tt.alg, tt.kid, tt.key, claims))
+			if rr.Code != http.StatusOK || rr.Body.String() != "jwt:user-1" {
+				t.Fatalf("Expected valid token to pass, got %d %q", rr.Code, rr.Body.String())
+			}
+		})
+	}
+
+	if rr := jwtRequest(router, "/api/me", ""); rr.Code != http.StatusUnauthorized || rr.Header().Get("WWW-Authenticate") != "Bearer" {
+		t.Errorf("Expected missing token to be rejected with Bearer challenge, got %d %q", rr.Code, rr.Header().Get("WWW-Authenticate"))
+	}
+	if rr := jwtRequest(router, "/public/info", ""); rr.Code != http.StatusOK {
+		t.Errorf("Expected public group to skip JWT, got %d", rr.Code)
+	}
+
+	// A token whose header claims HS256 but names the RSA key must not verify.
+	confused := signTestJWT(t, JWTAlgHS256, "rs", []byte("guess"), claims)
+	if rr := jwtRequest(router, "/api/me", confused); rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected algorithm mismatch to be rejected, got %d", rr.Code)
+	}
+
+	otherKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	forged := signTestJWT(t, JWTAlgES256, "es", otherKey, claims)
+	if rr := jwtRequest(router, "/api/me", forged); rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected bad signature to be rejected, got %d", rr.Code)
+	}
+}
+
+func TestJWTClaimsValidation(t *testing.T) {
+	secret := []byte("secret")
+	keys, _ := NewJWKS(JWK{ID: "k1", Algorithm: JWTAlgHS256, Key: secret})
+	now := time.Unix(1_700_000_000, 0)
+
+	config := JWTConfig{
+		Keys:      keys,
+		Issuers:   []string{"https://issuer.example.com"},
+		Audiences: []string{"orders-api"},
+		ClockSkew: 30 * time.Second,
+		now:       func() time.Time { return now },
+	}
+	router := New().Use(JWT(config))
+	router.GET("/", func(w http.ResponseWriter, r *http.Request) {
+		type orderClaims struct {
+			Tenant string `json:"tenant"`
+		}
+		custom, _ := JWTClaimsAs[orderClaims](r.Context())
+		claims, _ := JWTClaimsFrom(r.Context())
+		principal, _ := PrincipalFrom(r.Context())
+		w.Write([]byte(claims.Subject + " " + custom.Tenant + " " + principal.Permissions[0]))
+	})
+
+	base := func(overrides map[string]any) map[string]any {
+		claims := map[string]any{
+			"sub":    "user-1",
+			"iss":    "https://issuer.example.com",
+			"aud":    []string{"billing", "orders-api"},
+			"exp":    now.Add(time.Minute).Unix(),
+			"tenant": "acme",
+			"scope":  "orders:read orders:write",
+		}
+		for k, v := range overrides {
+			claims[k] = v
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name   string
+		claims map[string]any
+		status int
+	}{
+		{"valid", base(nil), http.StatusOK},
+		{"single audience string", base(map[string]any{"aud": "orders-api"}), http.StatusOK},
+		{"expired within skew", base(map[string]any{"exp": now.Add(-10 * time.Second).Unix()}), http.StatusOK},
+		{"expired", base(map[string]any{"exp": now.Add(-time.Minute).Unix()}), http.StatusUnauthorized},
+		{"not yet valid", base(map[string]any{"nbf": now.Add(time.Minute).Unix()}), http.StatusUnauthorized},
+		{"nbf within skew", base(map[string]any{"nbf": now.Add(10 * time.Second).Unix()}), http.StatusOK},
+		{"wrong issuer", base(map[string]any{"iss": "https://evil.example.com"}), http.StatusUnauthorized},
+		{"wrong audience", base(map[string]any{"aud": "billing"}), http.StatusUnauthorized},
+		{"nbf beyond year 9999", base(map[string]any{"nbf": 1e11}), http.StatusUnauthorized},
+		{"exp beyond year 9999", base(map[string]any{"exp": 1e19}), http.StatusUnauthorized},
+		{"negative exp", base(map[string]any{"exp": -1}), http.StatusUnauthorized},
+		{"fractional exp", base(map[string]any{"exp": float64(now.Unix()) + 0.5}), http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := jwtRequest(router, "/", signTestJWT(t, JWTAlgHS256, "k1", secret, tt.claims))
+			if rr.Code != tt.status {
+				t.Fatalf("Expected status %d, got %d", tt.status, rr.Code)
+			}
+			if tt.status == http.StatusOK && rr.Body.String() != "user-1 acme orders:read" {
+				t.Errorf("Unexpected claims in context: %q", rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestJWKSFileRotation(t *testing.T) {
+	writeKeys := func(path string, keys ...map[string]string) {
+		data, _ := json.Marshal(map[string]any{"keys": keys})
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	encode := base64.RawURLEncoding.EncodeToString
+
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	edPub, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecX, ecY := make([]byte, 32), make([]byte, 32)
+	ecKey.X.FillBytes(ecX)
+	ecKey.Y.FillBytes(ecY)
+
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	writeKeys(path,
+		map[string]string{"kty": "EC", "kid": "2024", "crv": "P-256", "x": encode(ecX), "y": encode(ecY)},
+		map[string]string{"kty": "RSA", "kid": "rsa", "n": encode(rsaKey.N.Bytes()), "e": "AQAB"},
+		map[string]string{"kty": "oct", "kid": "enc", "use": "enc", "k": encode([]byte("ignored"))},
+	)
+
+	keys, err := LoadJWKSFile(path)
+	if err != nil {
+		t.Fatalf("LoadJWKSFile: %v", err)
+	}
+	router := New().Use(JWT(JWTConfig{Keys: keys}))
+	router.GET("/", principalHandler)
+
+	claims := map[string]any{"sub": "svc"}
+	oldToken := signTestJWT(t, JWTAlgES256, "2024", ecKey, claims)
+	newToken := signTestJWT(t, JWTAlgEdDSA, "2025", edKey, claims)
+
+	if rr := jwtRequest(router, "/", oldToken); rr.Code != http.StatusOK {
+		t.Fatalf("Expected token for current key to pass, got %d", rr.Code)
+	}
+	if rr := jwtRequest(router, "/", signTestJWT(t, JWTAlgRS256, "rsa", rsaKey, claims)); rr.Code != http.StatusOK {
+		t.Fatalf("Expected RSA JWK to verify, got %d", rr.Code)
+	}
+	if rr := jwtRequest(router, "/", newToken); rr.Code != http.StatusUnauthorized {
+		t.Fatalf("Expected token for unknown kid to fail, got %d", rr.Code)
+	}
+
+	writeKeys(path, map[string]string{"kty": "OKP", "kid": "2025", "crv": "Ed25519", "x": encode(edPub)})
+	if err := keys.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+
+	if rr := jwtRequest(router, "/", newToken); rr.Code != http.StatusOK {
+		t.Errorf("Expected rotated key to verify, got %d", rr.Code)
+	}
+	if rr := jwtRequest(router, "/", oldToken); rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected retired key to fail, got %d", rr.Code)
+	}
+
+	writeKeys(path, map[string]string{"kty": "EC", "kid": "bad", "crv": "P-256", "x": encode(make([]byte, 32)), "y": encode(make([]byte, 32))})
+	if err := keys.Reload(); err == nil {
+		t.Error("Expected invalid EC point to be rejected")
+	}
+	if rr := jwtRequest(router, "/", newToken); rr.Code != http.StatusOK {
+		t.Errorf("Expected failed reload to keep previous keys, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,185 @@
+package simplerouter
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type OverflowPolicy int
+
+const (
+	DropOnOverflow OverflowPolicy = iota
+	BlockOnOverflow
+)
+
+var ErrLogWriterClosed = errors.New("simplerouter: log writer closed")
+
+type AsyncLogWriterConfig struct {
+	BufferSize    int
+	BatchSize     int
+	FlushInterval time.Duration
+	Policy        OverflowPolicy
+}
+
+type AsyncLogWriter struct {
+	out     io.Writer
+	config  AsyncLogWriterConfig
+	queue   chan logRecord
+	mu      sync.RWMutex
+	closed  bool
+	done    chan struct{}
+	dropped atomic.Uint64
+	written atomic.Uint64
+	errMu   sync.Mutex
+	err     error
+}
+
+type logRecord struct {
+	data  []byte
+	flush chan struct{}
+}
+
+func NewAsyncLogWriter(out io.Writer, config AsyncLogWriterConfig) *AsyncLogWriter {
+	if config.BufferSize <= 0 {
+		config.BufferSize = 1024
+	}
+	if config.BatchSize <= 0 {
+		config.BatchSize = 64
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = time.Second
+	}
+
+	w := &AsyncLogWriter{
+		out:    out,
+		config: config,
+		queue:  make(chan logRecord, config.BufferSize),
+		done:   make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+func (w *AsyncLogWriter) Write(p []byte) (int, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.closed {
+		return 0, ErrLogWriterClosed
+	}
+
+	record := logRecord{data: append([]byte(nil), p...)}
+	if w.config.Policy == BlockOnOverflow {
+		w.queue <- record
+		return len(p), nil
+	}
+
+	select {
+	case w.queue <- record:
+	default:
+		w.dropped.Add(1)
+	}
+	return len(p), nil
+}
+
+// Flush blocks until every record written before the call has reached the
+// underlying writer, and returns the first write error seen since the last
+// flush.
+func (w *AsyncLogWriter) Flush() error {
+	w.mu.RLock()
+	if w.closed {
+		w.mu.RUnlock()
+		return ErrLogWriterClosed
+	}
+	flushed := make(chan struct{})
+	w.queue <- logRecord{flush: flushed}
+	w.mu.RUnlock()
+
+	<-flushed
+	return w.takeErr()
+}
+
+func (w *AsyncLogWriter) Close() error {
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return ErrLogWriterClosed
+	}
+	w.closed = true
+	close(w.queue)
+	w.mu.Unlock()
+
+	<-w.done
+	return w.takeErr()
+}
+
+func (w *AsyncLogWriter) Dropped() uint64 {
+	return w.dropped.Load()
+}
+
+func (w *AsyncLogWriter) Written() uint64 {
+	return w.written.Load()
+}
+
+func (w *AsyncLogWriter) run() {
+	defer close(w.done)
+
+	ticker := time.NewTicker(w.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]byte, 0, 4096)
+	pending := 0
+
+	writeBatch := func() {
+		if pending == 0 {
+			return
+		}
+		if _, err := w.out.Write(batch); err != nil {
+			w.setErr(err)
+		}
+		w.written.Add(uint64(pending))
+		batch = batch[:0]
+		pending = 0
+	}
+
+	for {
+		select {
+		case record, ok := <-w.queue:
+			if !ok {
+				writeBatch()
+				return
+			}
+			if record.flush != nil {
+				writeBatch()
+				close(record.flush)
+				continue
+			}
+			batch = append(batch, record.data...)
+			pending++
+			if pending >= w.config.BatchSize {
+				writeBatch()
+			}
+		case <-ticker.C:
+			writeBatch()
+		}
+	}
+}
+
+func (w *AsyncLogWriter) setErr(err error) {
+	w.errMu.Lock()
+	if w.err == nil {
+		w.err = err
+	}
+	w.errMu.Unlock()
+}
+
+func (w *AsyncLogWriter) takeErr() error {
+	w.errMu.Lock()
+	defer w.errMu.Unlock()
+	err := w.err
+	w.err = nil
+	return err
+}
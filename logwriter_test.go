@@ -0,0 +1,157 @@
+package simplerouter
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+type blockingWriter struct {
+	release chan struct{}
+	out     syncBuffer
+}
+
+func (b *blockingWriter) Write(p []byte) (int, error) {
+	<-b.release
+	return b.out.Write(p)
+}
+
+func TestAsyncLogWriterFlush(t *testing.T) {
+	out := &syncBuffer{}
+	w := NewAsyncLogWriter(out, AsyncLogWriterConfig{
+		BatchSize:     100,
+		FlushInterval: time.Hour,
+	})
+	defer w.Close()
+
+	for i := 0; i < 10; i++ {
+		fmt.Fprintf(w, "line %d\n", i)
+	}
+
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush returned error: %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 10 {
+		t.Fatalf("Expected 10 lines, got %d", len(lines))
+	}
+	for i, line := range lines {
+		if line != fmt.Sprintf("line %d", i) {
+			t.Errorf("Expected line %d to be %q, got %q", i, fmt.Sprintf("line %d", i), line)
+		}
+	}
+	if w.Written() != 10 {
+		t.Errorf("Expected 10 written records, got %d", w.Written())
+	}
+}
+
+func TestAsyncLogWriterDropPolicy(t *testing.T) {
+	out := &blockingWriter{release: make(chan struct{})}
+	w := NewAsyncLogWriter(out, AsyncLogWriterConfig{
+		BufferSize: 2,
+		BatchSize:  1,
+		Policy:     DropOnOverflow,
+	})
+
+	for i := 0; i < 20; i++ {
+		if _, err := w.Write([]byte("x\n")); err != nil {
+			t.Fatalf("Write returned error: %v", err)
+		}
+	}
+
+	if w.Dropped() == 0 {
+		t.Error("Expected some records to be dropped")
+	}
+
+	close(out.release)
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	if got := uint64(strings.Count(out.out.String(), "x")); got+w.Dropped() != 20 {
+		t.Errorf("Expected written (%d) + dropped (%d) to equal 20", got, w.Dropped())
+	}
+}
+
+func TestAsyncLogWriterBlockPolicy(t *testing.T) {
+	out := &syncBuffer{}
+	w := NewAsyncLogWriter(out, AsyncLogWriterConfig{
+		BufferSize: 1,
+		BatchSize:  1,
+		Policy:     BlockOnOverflow,
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				w.Write([]byte("x\n"))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+	if w.Dropped() != 0 {
+		t.Errorf("Expected no dropped records, got %d", w.Dropped())
+	}
+	if got := strings.Count(out.String(), "x"); got != 400 {
+		t.Errorf("Expected 400 records, got %d", got)
+	}
+	if _, err := w.Write([]byte("late\n")); err != ErrLogWriterClosed {
+		t.Errorf("Expected ErrLogWriterClosed after Close, got %v", err)
+	}
+}
+
+func TestAccessLoggingConcurrentWrites(t *testing.T) {
+	var buf bytes.Buffer
+	router := New().Use(AccessLogging(AccessLogConfig{
+		Output: &buf,
+		Format: JSONLogFormat,
+	}))
+
+	router.GET("/test", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/test", nil))
+		}()
+	}
+	wg.Wait()
+
+	if got := strings.Count(buf.String(), "\n"); got != 50 {
+		t.Errorf("Expected 50 log lines, got %d", got)
+	}
+}
@@ -0,0 +1,227 @@
+package simplerouter
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	DefaultDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+	DefaultSizeBuckets     = []float64{100, 1000, 10000, 100000, 1000000, 10000000}
+)
+
+type MetricsConfig struct {
+	Namespace       string
+	DurationBuckets []float64
+	SizeBuckets     []float64
+}
+
+type Metrics struct {
+	config   MetricsConfig
+	mu       sync.Mutex
+	requests map[metricLabels]*requestSeries
+	inFlight map[metricLabels]int64
+}
+
+type metricLabels struct {
+	method string
+	route  string
+	status string
+}
+
+type requestSeries struct {
+	count    uint64
+	duration histogram
+	size     histogram
+}
+
+type histogram struct {
+	counts []uint64
+	sum    float64
+}
+
+func (h *histogram) observe(buckets []float64, value float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(buckets))
+	}
+	for i, upper := range buckets {
+		if value <= upper {
+			h.counts[i]++
+			break
+		}
+	}
+	h.sum += value
+}
+
+func NewMetrics(config MetricsConfig) *Metrics {
+	if config.Namespace == "" {
+		config.Namespace = "http"
+	}
+	if len(config.DurationBuckets) == 0 {
+		config.DurationBuckets = DefaultDurationBuckets
+	}
+	if len(config.SizeBuckets) == 0 {
+		config.SizeBuckets = DefaultSizeBuckets
+	}
+	config.DurationBuckets = append([]float64(nil), config.DurationBuckets...)
+	config.SizeBuckets = append([]float64(nil), config.SizeBuckets...)
+	sort.Float64s(config.DurationBuckets)
+	sort.Float64s(config.SizeBuckets)
+
+	return &Metrics{
+		config:   config,
+		requests: make(map[metricLabels]*requestSeries),
+		inFlight: make(map[metricLabels]int64),
+	}
+}
+
+func (m *Metrics) Middleware() Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			route := "unmatched"
+			if info, ok := RouteInfoFrom(r.Context()); ok {
+				route = info.Path
+			}
+			flight := metricLabels{method: r.Method, route: route}
+
+			m.mu.Lock()
+			m.inFlight[flight]++
+			m.mu.Unlock()
+
+			start := time.Now()
+			wrapped, ww := wrapResponseWriter(w)
+
+			completed := false
+			defer func() {
+				status := wrapped.Status()
+				if status == 0 {
+					status = http.StatusOK
+				}
+				if !completed {
+					status = http.StatusInternalServerError
+				}
+				m.observe(flight, status, time.Since(start), wrapped.BytesWritten())
+			}()
+
+			next(ww, r)
+			completed = true
+		}
+	}
+}
+
+func (m *Metrics) observe(flight metricLabels, status int, duration time.Duration, size int) {
+	labels := flight
+	labels.status = strconv.Itoa(status/100) + "xx"
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.inFlight[flight]--
+	series := m.requests[labels]
+	if series == nil {
+		series = &requestSeries{}
+		m.requests[labels] = series
+	}
+	series.count++
+	series.duration.observe(m.config.DurationBuckets, duration.Seconds())
+	series.size.observe(m.config.SizeBuckets, float64(size))
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		m.WriteTo(w)
+	})
+}
+
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	m.mu.Lock()
+	var b strings.Builder
+
+	keys := make([]metricLabels, 0, len(m.requests))
+	for labels := range m.requests {
+		keys = append(keys, labels)
+	}
+	sortLabels(keys)
+
+	flightKeys := make([]metricLabels, 0, len(m.inFlight))
+	for labels := range m.inFlight {
+		flightKeys = append(flightKeys, labels)
+	}
+	sortLabels(flightKeys)
+
+	ns := m.config.Namespace
+
+	writeMetricHeader(&b, ns+"_requests_total", "counter", "Total number of HTTP requests.")
+	for _, labels := range keys {
+		fmt.Fprintf(&b, "%s_requests_total{%s} %d\n", ns, labels.format(), m.requests[labels].count)
+	}
+
+	writeMetricHeader(&b, ns+"_requests_in_flight", "gauge", "Number of HTTP requests currently being served.")
+	for _, labels := range flightKeys {
+		fmt.Fprintf(&b, "%s_requests_in_flight{%s} %d\n", ns, labels.format(), m.inFlight[labels])
+	}
+
+	writeMetricHeader(&b, ns+"_request_duration_seconds", "histogram", "HTTP request latency in seconds.")
+	for _, labels := range keys {
+		series := m.requests[labels]
+		writeHistogram(&b, ns+"_request_duration_seconds", labels.format(), m.config.DurationBuckets, series.duration, series.count)
+	}
+
+	writeMetricHeader(&b, ns+"_response_size_bytes", "histogram", "HTTP response size in bytes.")
+	for _, labels := range keys {
+		series := m.requests[labels]
+		writeHistogram(&b, ns+"_response_size_bytes", labels.format(), m.config.SizeBuckets, series.size, series.count)
+	}
+	m.mu.Unlock()
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func sortLabels(keys []metricLabels) {
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].route != keys[j].route {
+			return keys[i].route < keys[j].route
+		}
+		if keys[i].method != keys[j].method {
+			return keys[i].method < keys[j].method
+		}
+		return keys[i].status < keys[j].status
+	})
+}
+
+func (l metricLabels) format() string {
+	s := `method="` + escapeLabelValue(l.method) + `",route="` + escapeLabelValue(l.route) + `"`
+	if l.status != "" {
+		s += `,status="` + l.status + `"`
+	}
+	return s
+}
+
+func escapeLabelValue(v string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+}
+
+func writeMetricHeader(b *strings.Builder, name, kind, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func writeHistogram(b *strings.Builder, name, labels string, buckets []float64, h histogram, count uint64) {
+	var cumulative uint64
+	for i, upper := range buckets {
+		if h.counts != nil {
+			cumulative += h.counts[i]
+		}
+		fmt.Fprintf(b, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, strconv.FormatFloat(upper, 'g', -1, 64), cumulative)
+	}
+	fmt.Fprintf(b, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, count)
+	fmt.Fprintf(b, "%s_sum{%s} %s\n", name, labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+	fmt.Fprintf(b, "%s_count{%s} %d\n", name, labels, count)
+}
@@ -0,0 +1,72 @@
+package simplerouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	metrics := NewMetrics(MetricsConfig{
+		SizeBuckets: []float64{10, 1000},
+	})
+	router := New().Use(metrics.Middleware())
+
+	var inFlight string
+	router.GET("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		var b strings.Builder
+		metrics.WriteTo(&b)
+		inFlight = b.String()
+		w.Write([]byte(strings.Repeat("x", 20)))
+	})
+	router.POST("/users", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad", http.StatusBadRequest)
+	})
+
+	for _, path := range []string{"/users/1", "/users/2", "/users/3"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/users", nil))
+
+	if !strings.Contains(inFlight, `http_requests_in_flight{method="GET",route="/users/{id}"} 1`) {
+		t.Errorf("Expected in-flight gauge of 1 during the request, got:\n%s", inFlight)
+	}
+
+	rr := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+	output := rr.Body.String()
+
+	if !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Errorf("Unexpected content type %q", rr.Header().Get("Content-Type"))
+	}
+
+	for _, want := range []string{
+		"# TYPE http_requests_total counter",
+		`http_requests_total{method="GET",route="/users/{id}",status="2xx"} 3`,
+		`http_requests_total{method="POST",route="/users",status="4xx"} 1`,
+		`http_requests_in_flight{method="GET",route="/users/{id}"} 0`,
+		"# TYPE http_request_duration_seconds histogram",
+		`http_request_duration_seconds_count{method="GET",route="/users/{id}",status="2xx"} 3`,
+		`http_response_size_bytes_bucket{method="GET",route="/users/{id}",status="2xx",le="10"} 0`,
+		`http_response_size_bytes_bucket{method="GET",route="/users/{id}",status="2xx",le="1000"} 3`,
+		`http_response_size_bytes_bucket{method="GET",route="/users/{id}",status="2xx",le="+Inf"} 3`,
+		`http_response_size_bytes_sum{method="GET",route="/users/{id}",status="2xx"} 60`,
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("Expected metrics output to contain %q, got:\n%s", want, output)
+		}
+	}
+
+	if strings.Contains(output, "/users/1") {
+		t.Error("Expected raw paths not to be used as labels")
+	}
+}
+
+func TestEscapeLabelValue(t *testing.T) {
+	got := escapeLabelValue("a\"b\\c\nd")
+	want := `a\"b\\c\nd`
+	if got != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
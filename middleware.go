@@ -1,12 +1,19 @@
 package simplerouter
 
 import (
-	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"net"
+	"math/rand/v2"
 	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -20,98 +27,336 @@ const (
 type AccessLogConfig struct {
 	Output io.Writer
 	Format AccessLogFormat
+
+	// Requests matching any skip rule are never logged.
+	SkipPaths    []string
+	SkipMethods  []string
+	SkipStatuses []int
+	Skip         func(r *http.Request, status int) bool
+
+	// SampleRate logs only a fraction (0-1) of 2xx responses; zero disables
+	// sampling. Non-2xx responses and requests slower than SlowThreshold are
+	// always logged.
+	SampleRate    float64
+	SlowThreshold time.Duration
+
+	// Headers and query parameters copied into the entry, and the redaction
+	// applied to the entry before it is written.
+	RequestHeaders  []string
+	ResponseHeaders []string
+	QueryParams     []string
+	Redaction       *RedactionConfig
+
+	// BodyCapture copies textual request and response bodies, up to a size
+	// cap, into the entry. Intended for debugging; nil disables it.
+	BodyCapture *BodyCaptureConfig
 }
 
-type AccessLogEntry struct {
-	RemoteAddr string    `json:"remote_addr"`
-	Method     string    `json:"method"`
-	Path       string    `json:"path"`
-	Status     int       `json:"status"`
-	Size       int       `json:"size"`
-	UserAgent  string    `json:"user_agent"`
-	Referer    string    `json:"referer"`
-	Duration   int64     `json:"duration_ms"`
-	Timestamp  time.Time `json:"timestamp"`
+func (c AccessLogConfig) skipRequest(r *http.Request) bool {
+	for _, prefix := range c.SkipPaths {
+		if strings.HasPrefix(r.URL.Path, prefix) {
+			return true
+		}
+	}
+	for _, method := range c.SkipMethods {
+		if strings.EqualFold(r.Method, method) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c AccessLogConfig) shouldLog(r *http.Request, status int, duration time.Duration) bool {
+	if status == 0 {
+		status = http.StatusOK
+	}
+	for _, s := range c.SkipStatuses {
+		if status == s {
+			return false
+		}
+	}
+	if c.Skip != nil && c.Skip(r, status) {
+		return false
+	}
+	if c.SampleRate <= 0 || c.SampleRate >= 1 {
+		return true
+	}
+	if status < 200 || status >= 300 {
+		return true
+	}
+	if c.SlowThreshold > 0 && duration >= c.SlowThreshold {
+		return true
+	}
+	return rand.Float64() < c.SampleRate
 }
 
-type responseWriter struct {
-	http.ResponseWriter
-	status int
-	size   int
+type AccessLogEntry struct {
+	RequestID   string        `json:"request_id,omitempty"`
+	TraceID     string        `json:"trace_id,omitempty"`
+	SpanID      string        `json:"span_id,omitempty"`
+	RemoteAddr  string        `json:"remote_addr"`
+	Method      string        `json:"method"`
+	Path        string        `json:"path"`
+	Query       string        `json:"query,omitempty"`
+	Protocol    string        `json:"protocol"`
+	Host        string        `json:"host"`
+	Route       string        `json:"route,omitempty"`
+	RouteName   string        `json:"route_name,omitempty"`
+	Status      int           `json:"status"`
+	Size        int           `json:"size"`
+	RequestSize int64         `json:"request_size"`
+	UserAgent   string        `json:"user_agent"`
+	Referer     string        `json:"referer"`
+	TLSVersion  string        `json:"tls_version,omitempty"`
+	TLSCipher   string        `json:"tls_cipher,omitempty"`
+	Error       string        `json:"error,omitempty"`
+	Panic       bool          `json:"panic,omitempty"`
+	Duration    time.Duration `json:"duration_ns"`
+	Timestamp   time.Time     `json:"timestamp"`
+
+	RequestHeaders  map[string]string `json:"request_headers,omitempty"`
+	ResponseHeaders map[string]string `json:"response_headers,omitempty"`
+	QueryParams     map[string]string `json:"query_params,omitempty"`
+
+	RequestBody           string `json:"request_body,omitempty"`
+	RequestBodyTruncated  bool   `json:"request_body_truncated,omitempty"`
+	ResponseBody          string `json:"response_body,omitempty"`
+	ResponseBodyTruncated bool   `json:"response_body_truncated,omitempty"`
 }
 
-func (rw *responseWriter) WriteHeader(status int) {
-	rw.status = status
-	rw.ResponseWriter.WriteHeader(status)
+type accessLogState struct {
+	mu        sync.Mutex
+	errors    []error
+	panicked  bool
+	requestID string
+	trace     SpanContext
+	client    *ForwardedInfo
 }
 
-func (rw *responseWriter) Write(b []byte) (int, error) {
-	if rw.status == 0 {
-		rw.status = http.StatusOK
+type accessLogStateKey struct{}
+
+func LogError(r *http.Request, err error) {
+	if err == nil {
+		return
 	}
-	n, err := rw.ResponseWriter.Write(b)
-	rw.size += n
-	return n, err
+	state, ok := r.Context().Value(accessLogStateKey{}).(*accessLogState)
+	if !ok {
+		return
+	}
+	state.mu.Lock()
+	state.errors = append(state.errors, err)
+	state.mu.Unlock()
 }
 
-func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
-	if hijacker, ok := rw.ResponseWriter.(http.Hijacker); ok {
-		return hijacker.Hijack()
+func markPanic(r *http.Request, err error) {
+	state, ok := r.Context().Value(accessLogStateKey{}).(*accessLogState)
+	if !ok {
+		return
 	}
-	return nil, nil, fmt.Errorf("responseWriter does not implement http.Hijacker")
+	state.mu.Lock()
+	state.panicked = true
+	state.errors = append(state.errors, err)
+	state.mu.Unlock()
 }
 
-func (rw *responseWriter) Flush() {
-	if flusher, ok := rw.ResponseWriter.(http.Flusher); ok {
-		flusher.Flush()
+func setLogRequestID(r *http.Request, id string) {
+	state, ok := r.Context().Value(accessLogStateKey{}).(*accessLogState)
+	if !ok {
+		return
 	}
+	state.mu.Lock()
+	state.requestID = id
+	state.mu.Unlock()
 }
 
-func (rw *responseWriter) CloseNotify() <-chan bool {
-	if notifier, ok := rw.ResponseWriter.(http.CloseNotifier); ok {
-		return notifier.CloseNotify()
+func setLogTrace(r *http.Request, sc SpanContext) {
+	state, ok := r.Context().Value(accessLogStateKey{}).(*accessLogState)
+	if !ok {
+		return
 	}
-	return make(<-chan bool)
+	state.mu.Lock()
+	state.trace = sc
+	state.mu.Unlock()
 }
 
-func (rw *responseWriter) Push(target string, opts *http.PushOptions) error {
-	if pusher, ok := rw.ResponseWriter.(http.Pusher); ok {
-		return pusher.Push(target, opts)
+func setLogClient(r *http.Request, info ForwardedInfo) {
+	state, ok := r.Context().Value(accessLogStateKey{}).(*accessLogState)
+	if !ok {
+		return
 	}
-	return fmt.Errorf("responseWriter does not implement http.Pusher")
+	state.mu.Lock()
+	state.client = &info
+	state.mu.Unlock()
+}
+
+func (s *accessLogState) getClient() *ForwardedInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.client
+}
+
+func (s *accessLogState) getTrace() SpanContext {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.trace
+}
+
+func (s *accessLogState) getRequestID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requestID
+}
+
+func (s *accessLogState) hasPanicked() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.panicked
+}
+
+func (s *accessLogState) err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return errors.Join(s.errors...)
+}
+
+// countingReader is safe for concurrent use because a handler abandoned by
+// Timeout may still be reading the body while the log entry is built.
+type countingReader struct {
+	io.ReadCloser
+	n       atomic.Int64
+	capture *bodyCapture
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n.Add(int64(n))
+	c.capture.capture(p[:n])
+	return n, err
 }
 
 func AccessLogging(config AccessLogConfig) Middleware {
+	var mu sync.Mutex
+	if config.Redaction != nil {
+		config.Redaction = config.Redaction.compile()
+	}
+
 	return func(next HandlerFunc) HandlerFunc {
 		return func(w http.ResponseWriter, r *http.Request) {
+			if config.skipRequest(r) {
+				next(w, r)
+				return
+			}
+
 			start := time.Now()
-			wrapped := &responseWriter{
-				ResponseWriter: w,
-				status:         0,
-				size:           0,
+			wrapped, ww := wrapResponseWriter(w)
+
+			state := &accessLogState{requestID: RequestIDFrom(r.Context())}
+			state.trace, _ = SpanContextFrom(r.Context())
+			if info, ok := ForwardedFrom(r.Context()); ok {
+				state.client = &info
 			}
+			r = r.WithContext(context.WithValue(r.Context(), accessLogStateKey{}, state))
 
-			next(wrapped, r)
-
-			duration := time.Since(start)
-			entry := AccessLogEntry{
-				RemoteAddr: r.RemoteAddr,
-				Method:     r.Method,
-				Path:       r.URL.Path,
-				Status:     wrapped.status,
-				Size:       wrapped.size,
-				UserAgent:  r.UserAgent(),
-				Referer:    r.Referer(),
-				Duration:   duration.Milliseconds(),
-				Timestamp:  start,
+			var requestCapture, responseCapture *bodyCapture
+			if config.BodyCapture != nil {
+				requestCapture = &bodyCapture{limit: config.BodyCapture.limit()}
+				responseCapture = &bodyCapture{limit: config.BodyCapture.limit()}
+				ww.Tee(responseCapture)
 			}
 
-			switch config.Format {
-			case JSONLogFormat:
-				logJSON(config.Output, entry)
-			case CombinedLogFormat:
-				logCombined(config.Output, entry)
+			var body *countingReader
+			if r.Body != nil && r.Body != http.NoBody {
+				body = &countingReader{ReadCloser: r.Body, capture: requestCapture}
+				r.Body = body
 			}
+
+			// Log from a deferred call so requests whose handler panics are
+			// still recorded; the panic itself keeps unwinding untouched.
+			completed := false
+			defer func() {
+				panicked := !completed || state.hasPanicked()
+				status := wrapped.Status()
+				if panicked && status == 0 {
+					status = http.StatusInternalServerError
+				}
+
+				duration := time.Since(start)
+				if !config.shouldLog(r, status, duration) {
+					return
+				}
+
+				entry := AccessLogEntry{
+					RequestID:  state.getRequestID(),
+					RemoteAddr: r.RemoteAddr,
+					Method:     r.Method,
+					Path:       r.URL.Path,
+					Query:      r.URL.RawQuery,
+					Protocol:   r.Proto,
+					Host:       r.Host,
+					Status:     status,
+					Size:       wrapped.BytesWritten(),
+					UserAgent:  r.UserAgent(),
+					Referer:    r.Referer(),
+					Panic:      panicked,
+					Duration:   duration,
+					Timestamp:  start,
+
+					RequestHeaders:  captureHeaders(r.Header, config.RequestHeaders),
+					ResponseHeaders: captureHeaders(wrapped.Header(), config.ResponseHeaders),
+					QueryParams:     captureQueryParams(r.URL.Query(), config.QueryParams),
+				}
+
+				if client := state.getClient(); client != nil {
+					if client.ClientIP.IsValid() {
+						entry.RemoteAddr = client.ClientIP.String()
+					}
+					entry.Host = client.Host
+				}
+				if sc := state.getTrace(); sc.IsValid() {
+					entry.TraceID = sc.TraceID.String()
+					entry.SpanID = sc.SpanID.String()
+				}
+				if info, ok := RouteInfoFrom(r.Context()); ok {
+					entry.Route = info.Path
+					entry.RouteName = info.Name
+				}
+				if body != nil {
+					entry.RequestSize = body.n.Load()
+				}
+				if r.TLS != nil {
+					entry.TLSVersion = tls.VersionName(r.TLS.Version)
+					entry.TLSCipher = tls.CipherSuiteName(r.TLS.CipherSuite)
+				}
+				if err := errors.Join(state.err(), wrapped.err); err != nil {
+					entry.Error = err.Error()
+				}
+				if config.BodyCapture != nil {
+					entry.RequestBody, entry.RequestBodyTruncated = requestCapture.body(
+						r.Header.Get("Content-Type"), config.BodyCapture, config.Redaction)
+					entry.ResponseBody, entry.ResponseBodyTruncated = responseCapture.body(
+						wrapped.Header().Get("Content-Type"), config.BodyCapture, config.Redaction)
+				}
+				if config.Redaction != nil {
+					config.Redaction.redactEntry(&entry)
+				}
+
+				// Format into a buffer first so each entry reaches the output in a
+				// single Write, serialised across concurrent requests.
+				var buf bytes.Buffer
+				switch config.Format {
+				case JSONLogFormat:
+					logJSON(&buf, entry)
+				case CombinedLogFormat:
+					logCombined(&buf, entry)
+				}
+
+				mu.Lock()
+				config.Output.Write(buf.Bytes())
+				mu.Unlock()
+			}()
+
+			next(ww, r)
+			completed = true
 		}
 	}
 }
@@ -123,15 +368,66 @@ func logJSON(output io.Writer, entry AccessLogEntry) {
 
 func logCombined(output io.Writer, entry AccessLogEntry) {
 	timestamp := entry.Timestamp.Format("02/Jan/2006:15:04:05 -0700")
-	fmt.Fprintf(output, "%s - - [%s] \"%s %s HTTP/1.1\" %d %d \"%s\" \"%s\" %dms\n",
+	requestURI := entry.Path
+	if entry.Query != "" {
+		requestURI += "?" + entry.Query
+	}
+
+	var extra strings.Builder
+	fmt.Fprintf(&extra, " host=%q req_size=%d", entry.Host, entry.RequestSize)
+	if entry.RequestID != "" {
+		fmt.Fprintf(&extra, " request_id=%q", entry.RequestID)
+	}
+	if entry.TraceID != "" {
+		fmt.Fprintf(&extra, " trace_id=%s span_id=%s", entry.TraceID, entry.SpanID)
+	}
+	if entry.Route != "" {
+		fmt.Fprintf(&extra, " route=%q", entry.Route)
+	}
+	if entry.RouteName != "" {
+		fmt.Fprintf(&extra, " route_name=%q", entry.RouteName)
+	}
+	if entry.TLSVersion != "" {
+		fmt.Fprintf(&extra, " tls=%q cipher=%q", entry.TLSVersion, entry.TLSCipher)
+	}
+	if entry.Error != "" {
+		fmt.Fprintf(&extra, " error=%q", entry.Error)
+	}
+	if entry.Panic {
+		extra.WriteString(" panic=true")
+	}
+	writeFields(&extra, "req_header.", entry.RequestHeaders)
+	writeFields(&extra, "resp_header.", entry.ResponseHeaders)
+	writeFields(&extra, "query.", entry.QueryParams)
+	if entry.RequestBody != "" {
+		fmt.Fprintf(&extra, " req_body=%q", entry.RequestBody)
+	}
+	if entry.ResponseBody != "" {
+		fmt.Fprintf(&extra, " resp_body=%q", entry.ResponseBody)
+	}
+
+	fmt.Fprintf(output, "%s - - [%s] \"%s %s %s\" %d %d \"%s\" \"%s\" %.3fms%s\n",
 		entry.RemoteAddr,
 		timestamp,
 		entry.Method,
-		entry.Path,
+		requestURI,
+		entry.Protocol,
 		entry.Status,
 		entry.Size,
 		entry.Referer,
 		entry.UserAgent,
-		entry.Duration,
+		float64(entry.Duration)/float64(time.Millisecond),
+		extra.String(),
 	)
 }
+
+func writeFields(w io.Writer, prefix string, fields map[string]string) {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(w, " %s%s=%q", prefix, key, fields[key])
+	}
+}
@@ -0,0 +1,218 @@
+package simplerouter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAccessLoggingJSON(t *testing.T) {
+	var buf bytes.Buffer
+	router := New().Use(AccessLogging(AccessLogConfig{
+		Output: &buf,
+		Format: JSONLogFormat,
+	}))
+
+	router.Route("/users/{id}").Name("user").POST(func(w http.ResponseWriter, r *http.Request) {
+		body := new(bytes.Buffer)
+		body.ReadFrom(r.Body)
+		LogError(r, errors.New("something failed"))
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("created"))
+	})
+
+	req := httptest.NewRequest("POST", "/users/42?expand=true", strings.NewReader("payload"))
+	req.Host = "example.com"
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	var entry AccessLogEntry
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to decode log entry: %v", err)
+	}
+
+	if entry.Path != "/users/42" {
+		t.Errorf("Expected path %q, got %q", "/users/42", entry.Path)
+	}
+	if entry.Route != "/users/{id}" {
+		t.Errorf("Expected route %q, got %q", "/users/{id}", entry.Route)
+	}
+	if entry.RouteName != "user" {
+		t.Errorf("Expected route name %q, got %q", "user", entry.RouteName)
+	}
+	if entry.Query != "expand=true" {
+		t.Errorf("Expected query %q, got %q", "expand=true", entry.Query)
+	}
+	if entry.Host != "example.com" {
+		t.Errorf("Expected host %q, got %q", "example.com", entry.Host)
+	}
+	if entry.Protocol != "HTTP/1.1" {
+		t.Errorf("Expected protocol %q, got %q", "HTTP/1.1", entry.Protocol)
+	}
+	if entry.Status != http.StatusCreated {
+		t.Errorf("Expected status %d, got %d", http.StatusCreated, entry.Status)
+	}
+	if entry.Size != len("created") {
+		t.Errorf("Expected size %d, got %d", len("created"), entry.Size)
+	}
+	if entry.RequestSize != int64(len("payload")) {
+		t.Errorf("Expected request size %d, got %d", len("payload"), entry.RequestSize)
+	}
+	if entry.Error != "something failed" {
+		t.Errorf("Expected error %q, got %q", "something failed", entry.Error)
+	}
+	if entry.Duration <= 0 {
+		t.Errorf("Expected positive duration, got %v", entry.Duration)
+	}
+}
+
+func TestAccessLoggingCombined(t *testing.T) {
+	var buf bytes.Buffer
+	router := New().Use(AccessLogging(AccessLogConfig{
+		Output: &buf,
+		Format: CombinedLogFormat,
+	}))
+
+	router.GET("/items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+
+	req := httptest.NewRequest("GET", "/items/7?page=2", nil)
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	line := buf.String()
+	for _, want := range []string{
+		`"GET /items/7?page=2 HTTP/1.1" 200 2`,
+		`route="/items/{id}"`,
+		`host="example.com"`,
+	} {
+		if !strings.Contains(line, want) {
+			t.Errorf("Expected log line to contain %q, got %q", want, line)
+		}
+	}
+}
+
+func TestRouteInfoFrom(t *testing.T) {
+	router := New()
+
+	var info RouteInfo
+	var found bool
+	router.Group("/api").GET("/orders/{id}", func(w http.ResponseWriter, r *http.Request) {
+		info, found = RouteInfoFrom(r.Context())
+	})
+
+	req := httptest.NewRequest("GET", "/api/orders/1", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !found {
+		t.Fatal("Expected route info in request context")
+	}
+	if info.Path != "/api/orders/{id}" || info.Method != "GET" || info.Prefix != "/api" {
+		t.Errorf("Unexpected route info %+v", info)
+	}
+
+	if _, ok := RouteInfoFrom(httptest.NewRequest("GET", "/", nil).Context()); ok {
+		t.Error("Expected no route info outside the router")
+	}
+}
+
+func TestAccessLoggingSkipRules(t *testing.T) {
+	var buf bytes.Buffer
+	router := New().Use(AccessLogging(AccessLogConfig{
+		Output:       &buf,
+		Format:       JSONLogFormat,
+		SkipPaths:    []string{"/healthz"},
+		SkipMethods:  []string{"OPTIONS"},
+		SkipStatuses: []int{http.StatusNotModified},
+		Skip: func(r *http.Request, status int) bool {
+			return r.Header.Get("X-No-Log") != ""
+		},
+	}))
+
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/cached" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Write([]byte("ok"))
+	}
+	router.GET("/healthz", handler)
+	router.GET("/healthz/ready", handler)
+	router.OPTIONS("/api", handler)
+	router.GET("/cached", handler)
+	router.GET("/api", handler)
+
+	tests := []struct {
+		method    string
+		path      string
+		header    string
+		expectLog bool
+	}{
+		{"GET", "/healthz", "", false},
+		{"GET", "/healthz/ready", "", false},
+		{"OPTIONS", "/api", "", false},
+		{"GET", "/cached", "", false},
+		{"GET", "/api", "yes", false},
+		{"GET", "/api", "", true},
+	}
+
+	for _, tt := range tests {
+		buf.Reset()
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		if tt.header != "" {
+			req.Header.Set("X-No-Log", tt.header)
+		}
+		router.ServeHTTP(httptest.NewRecorder(), req)
+
+		if logged := buf.Len() > 0; logged != tt.expectLog {
+			t.Errorf("%s %s: expected logged=%v, got %v", tt.method, tt.path, tt.expectLog, logged)
+		}
+	}
+}
+
+func TestAccessLoggingSampling(t *testing.T) {
+	var buf bytes.Buffer
+	router := New().Use(AccessLogging(AccessLogConfig{
+		Output:        &buf,
+		Format:        JSONLogFormat,
+		SampleRate:    1e-12,
+		SlowThreshold: 20 * time.Millisecond,
+	}))
+
+	router.GET("/ok", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})
+	router.GET("/error", func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	})
+	router.GET("/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(25 * time.Millisecond)
+		w.Write([]byte("ok"))
+	})
+
+	tests := []struct {
+		path      string
+		expectLog bool
+	}{
+		{"/ok", false},
+		{"/error", true},
+		{"/slow", true},
+	}
+
+	for _, tt := range tests {
+		buf.Reset()
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", tt.path, nil))
+
+		if logged := buf.Len() > 0; logged != tt.expectLog {
+			t.Errorf("%s: expected logged=%v, got %v", tt.path, tt.expectLog, logged)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package simplerouter
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+)
+
+var (
+	ErrClientCertMissing    = errors.New("simplerouter: no verified client certificate")
+	ErrClientCertNotAllowed = errors.New("simplerouter: client certificate not in allowlist")
+)
+
+type ClientCertMode int
+
+const (
+	// RequireClientCert rejects TLS handshakes without a certificate signed
+	// by the configured CA pool.
+	RequireClientCert ClientCertMode = iota
+	// RequestClientCert asks for a certificate and verifies it if one is
+	// sent, but lets clients without one connect. Use ClientCertAuth to
+	// protect individual Groups.
+	RequestClientCert
+)
+
+type MTLSConfig struct {
+	ClientCAFile string
+	ClientCAs    *x509.CertPool
+	Mode         ClientCertMode
+	MinVersion   uint16
+}
+
+// NewMTLSServerConfig builds a server tls.Config that verifies client
+// certificates against ClientCAs and the PEM bundle in ClientCAFile.
+func NewMTLSServerConfig(config MTLSConfig) (*tls.Config, error) {
+	pool := config.ClientCAs
+	if config.ClientCAFile != "" {
+		pem, err := os.ReadFile(config.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		if pool == nil {
+			pool = x509.NewCertPool()
+		} else {
+			pool = pool.Clone()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("simplerouter: no certificates found in %s", config.ClientCAFile)
+		}
+	}
+	if pool == nil {
+		return nil, errors.New("simplerouter: mTLS requires a client CA pool")
+	}
+
+	clientAuth := tls.RequireAndVerifyClientCert
+	if config.Mode == RequestClientCert {
+		clientAuth = tls.VerifyClientCertIfGiven
+	}
+	minVersion := config.MinVersion
+	if minVersion == 0 {
+		minVersion = tls.VersionTLS12
+	}
+
+	return &tls.Config{
+		ClientCAs:  pool,
+		ClientAuth: clientAuth,
+		MinVersion: minVersion,
+	}, nil
+}
+
+func (r *Router) ListenAndServeMTLS(addr, certFile, keyFile string, config MTLSConfig) error {
+	tlsConfig, err := NewMTLSServerConfig(config)
+	if err != nil {
+		return err
+	}
+
+	r.PrintRoutes()
+	server := &http.Server{
+		Addr:      addr,
+		Handler:   r,
+		TLSConfig: tlsConfig,
+	}
+	return server.ListenAndServeTLS(certFile, keyFile)
+}
+
+// ClientCertConfig restricts which verified client certificates are accepted.
+// Entries ending in "*" match by prefix, so "spiffe://example.org/ns/billing/*"
+// admits every workload in that namespace. Fingerprints are SHA-256, hex
+// encoded, with or without colons. When every list is empty any certificate
+// that passed TLS verification is accepted.
+type ClientCertConfig struct {
+	AllowedCommonNames  []string
+	AllowedURIs         []string
+	AllowedDNSNames     []string
+	AllowedFingerprints []string
+}
+
+// ClientCertAuth maps the verified client certificate to a Principal. The ID
+// is the first SPIFFE URI SAN, falling back to the first URI SAN and then the
+// subject common name.
+func ClientCertAuth(config ClientCertConfig) Middleware {
+	fingerprints := make([]string, len(config.AllowedFingerprints))
+	for i, fingerprint := range config.AllowedFingerprints {
+		fingerprints[i] = normalizeFingerprint(fingerprint)
+	}
+	config.AllowedFingerprints = fingerprints
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+				LogError(r, ErrClientCertMissing)
+				unauthorized(w, "")
+				return
+			}
+
+			cert := r.TLS.VerifiedChains[0][0]
+			principal := certificatePrincipal(cert)
+			if !config.allows(cert, principal.Attributes["fingerprint"].(string)) {
+				LogError(r, ErrClientCertNotAllowed)
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+			next(w, r.WithContext(WithPrincipal(r.Context(), principal)))
+		}
+	}
+}
+
+func certificatePrincipal(cert *x509.Certificate) *Principal {
+	sum := sha256.Sum256(cert.Raw)
+	uris := make([]string, len(cert.URIs))
+	for i, uri := range cert.URIs {
+		uris[i] = uri.String()
+	}
+
+	id := cert.Subject.CommonName
+	if len(uris) > 0 {
+		id = uris[0]
+		if i := slices.IndexFunc(uris, func(uri string) bool { return strings.HasPrefix(uri, "spiffe://") }); i >= 0 {
+			id = uris[i]
+		}
+	}
+
+	return &Principal{
+		ID:     id,
+		Method: "mtls",
+		Attributes: map[string]any{
+			"common_name": cert.Subject.CommonName,
+			"uris":        uris,
+			"dns_names":   cert.DNSNames,
+			"fingerprint": hex.EncodeToString(sum[:]),
+			"serial":      cert.SerialNumber.String(),
+			"issuer":      cert.Issuer.String(),
+		},
+	}
+}
+
+func (c ClientCertConfig) allows(cert *x509.Certificate, fingerprint string) bool {
+	if len(c.AllowedCommonNames) == 0 && len(c.AllowedURIs) == 0 &&
+		len(c.AllowedDNSNames) == 0 && len(c.AllowedFingerprints) == 0 {
+		return true
+	}
+
+	if matchesAllowlist(c.AllowedCommonNames, cert.Subject.CommonName) ||
+		slices.Contains(c.AllowedFingerprints, fingerprint) {
+		return true
+	}
+	for _, uri := range cert.URIs {
+		if matchesAllowlist(c.AllowedURIs, uri.String()) {
+			return true
+		}
+	}
+	for _, name := range cert.DNSNames {
+		if matchesAllowlist(c.AllowedDNSNames, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAllowlist(allowlist []string, value string) bool {
+	if value == "" {
+		return false
+	}
+	for _, allowed := range allowlist {
+		if prefix, ok := strings.CutSuffix(allowed, "*"); ok {
+			if strings.HasPrefix(value, prefix) {
+				return true
+			}
+		} else if allowed == value {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeFingerprint(fingerprint string) string {
+	return strings.ToLower(strings.ReplaceAll(fingerprint, ":", ""))
+}
@@ -0,0 +1,183 @@
+package simplerouter
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key}
+}
+
+func (ca *testCA) issue(t *testing.T, commonName string, uris ...string) tls.Certificate {
+	t.Helper()
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	for _, raw := range uris {
+		uri, _ := url.Parse(raw)
+		template.URIs = append(template.URIs, uri)
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func newMTLSServer(t *testing.T, ca *testCA, mode ClientCertMode, handler http.Handler) *httptest.Server {
+	t.Helper()
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.cert.Raw})
+	if err := os.WriteFile(caFile, pemBytes, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tlsConfig, err := NewMTLSServerConfig(MTLSConfig{ClientCAFile: caFile, Mode: mode})
+	if err != nil {
+		t.Fatalf("NewMTLSServerConfig: %v", err)
+	}
+
+	server := httptest.NewUnstartedServer(handler)
+	server.TLS = tlsConfig
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server
+}
+
+func mtlsClient(server *httptest.Server, certs ...tls.Certificate) *http.Client {
+	client := server.Client()
+	transport := client.Transport.(*http.Transport).Clone()
+	transport.TLSClientConfig.Certificates = certs
+	client.Transport = transport
+	return client
+}
+
+func mtlsGet(t *testing.T, client *http.Client, url string) (int, string) {
+	t.Helper()
+	resp, err := client.Get(url)
+	if err != nil {
+		return 0, err.Error()
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestClientCertAuth(t *testing.T) {
+	ca := newTestCA(t)
+	billing := ca.issue(t, "billing", "spiffe://example.org/ns/billing/sa/worker")
+	orders := ca.issue(t, "orders", "spiffe://example.org/ns/orders/sa/api")
+	sum := sha256.Sum256(orders.Certificate[0])
+
+	router := New()
+	router.Group("/billing").
+		Use(ClientCertAuth(ClientCertConfig{AllowedURIs: []string{"spiffe://example.org/ns/billing/*"}})).
+		GET("/invoices", principalHandler)
+	router.Group("/orders").
+		Use(ClientCertAuth(ClientCertConfig{AllowedFingerprints: []string{hex.EncodeToString(sum[:])}})).
+		GET("/list", principalHandler)
+	router.GET("/health", okHandler)
+
+	server := newMTLSServer(t, ca, RequestClientCert, router)
+
+	tests := []struct {
+		name   string
+		cert   []tls.Certificate
+		path   string
+		status int
+		body   string
+	}{
+		{"spiffe allowlist", []tls.Certificate{billing}, "/billing/invoices", http.StatusOK, "mtls:spiffe://example.org/ns/billing/sa/worker"},
+		{"spiffe not allowed", []tls.Certificate{orders}, "/billing/invoices", http.StatusForbidden, ""},
+		{"fingerprint allowlist", []tls.Certificate{orders}, "/orders/list", http.StatusOK, "mtls:spiffe://example.org/ns/orders/sa/api"},
+		{"fingerprint not allowed", []tls.Certificate{billing}, "/orders/list", http.StatusForbidden, ""},
+		{"no cert on protected group", nil, "/billing/invoices", http.StatusUnauthorized, ""},
+		{"no cert on open route", nil, "/health", http.StatusOK, "ok"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := mtlsGet(t, mtlsClient(server, tt.cert...), server.URL+tt.path)
+			if status != tt.status {
+				t.Fatalf("Expected status %d, got %d (%s)", tt.status, status, body)
+			}
+			if tt.body != "" && body != tt.body {
+				t.Errorf("Expected body %q, got %q", tt.body, body)
+			}
+		})
+	}
+}
+
+func TestRequireClientCert(t *testing.T) {
+	ca := newTestCA(t)
+	router := New().Use(ClientCertAuth(ClientCertConfig{AllowedCommonNames: []string{"ops-*"}}))
+	router.GET("/", principalHandler)
+	server := newMTLSServer(t, ca, RequireClientCert, router)
+
+	if status, body := mtlsGet(t, mtlsClient(server, ca.issue(t, "ops-laptop")), server.URL); status != http.StatusOK || body != "mtls:ops-laptop" {
+		t.Errorf("Expected common name principal, got %d %q", status, body)
+	}
+	if status, _ := mtlsGet(t, mtlsClient(server), server.URL); status != 0 {
+		t.Errorf("Expected handshake failure without a client certificate, got %d", status)
+	}
+
+	untrusted := newTestCA(t).issue(t, "ops-laptop")
+	if status, _ := mtlsGet(t, mtlsClient(server, untrusted), server.URL); status != 0 {
+		t.Errorf("Expected handshake failure for certificate from another CA, got %d", status)
+	}
+}
+
+func TestClientCertAuthWithoutTLS(t *testing.T) {
+	router := New().Use(ClientCertAuth(ClientCertConfig{}))
+	router.GET("/", okHandler)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("Expected 401 for plaintext request, got %d", rr.Code)
+	}
+}
@@ -0,0 +1,224 @@
+package simplerouter
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+type ProxyHeadersConfig struct {
+	// TrustedProxies lists the CIDR prefixes or addresses of load balancers
+	// and reverse proxies whose forwarding headers are believed. Headers from
+	// any other peer are ignored.
+	TrustedProxies []string
+}
+
+// ForwardedInfo describes the original client connection as reported by
+// trusted proxies.
+type ForwardedInfo struct {
+	ClientIP netip.Addr
+	Scheme   string
+	Host     string
+}
+
+type forwardedInfoKey struct{}
+
+// ProxyHeaders resolves the client IP, scheme and host from the Forwarded
+// (RFC 7239), X-Forwarded-For/Proto/Host and X-Real-IP headers, in that
+// order of preference, when the peer is a trusted proxy. The address chain
+// is walked from the right, skipping trusted proxies, so entries a client
+// prepends cannot be used to spoof its address.
+func ProxyHeaders(config ProxyHeadersConfig) Middleware {
+	trusted, err := ParsePrefixes(config.TrustedProxies)
+	if err != nil {
+		panic(err)
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			info := resolveForwarded(r, trusted)
+			setLogClient(r, info)
+			next(w, r.WithContext(context.WithValue(r.Context(), forwardedInfoKey{}, info)))
+		}
+	}
+}
+
+// RealIP is ProxyHeaders with only a list of trusted proxies.
+func RealIP(trustedProxies ...string) Middleware {
+	return ProxyHeaders(ProxyHeadersConfig{TrustedProxies: trustedProxies})
+}
+
+func ForwardedFrom(ctx context.Context) (ForwardedInfo, bool) {
+	info, ok := ctx.Value(forwardedInfoKey{}).(ForwardedInfo)
+	return info, ok
+}
+
+// ClientIP returns the client address resolved by ProxyHeaders, falling back
+// to the host part of r.RemoteAddr.
+func ClientIP(r *http.Request) string {
+	if info, ok := ForwardedFrom(r.Context()); ok && info.ClientIP.IsValid() {
+		return info.ClientIP.String()
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func resolveForwarded(r *http.Request, trusted []netip.Prefix) ForwardedInfo {
+	info := ForwardedInfo{
+		ClientIP: parseRemoteAddr(r.RemoteAddr),
+		Scheme:   "http",
+		Host:     r.Host,
+	}
+	if r.TLS != nil {
+		info.Scheme = "https"
+	}
+	if !containsAddr(trusted, info.ClientIP) {
+		return info
+	}
+
+	if elements := parseForwardedHeader(r.Header.Values("Forwarded")); len(elements) > 0 {
+		hops := make([]string, len(elements))
+		for i, element := range elements {
+			hops[i] = element["for"]
+		}
+		addr, index := walkForwardedFor(hops, info.ClientIP, trusted, parseForwardedNode)
+		info.ClientIP = addr
+		if index >= 0 {
+			info.applySchemeAndHost(elements[index]["proto"], elements[index]["host"])
+		}
+		return info
+	}
+
+	if values := r.Header.Values("X-Forwarded-For"); len(values) > 0 {
+		hops := strings.Split(strings.Join(values, ","), ",")
+		addr, index := walkForwardedFor(hops, info.ClientIP, trusted, parseForwardedNode)
+		info.ClientIP = addr
+		info.applySchemeAndHost(
+			forwardedListValue(r.Header.Values("X-Forwarded-Proto"), index, len(hops)),
+			forwardedListValue(r.Header.Values("X-Forwarded-Host"), index, len(hops)),
+		)
+		return info
+	}
+
+	if realIP, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+		info.ClientIP = realIP.Unmap()
+	}
+	info.applySchemeAndHost(
+		forwardedListValue(r.Header.Values("X-Forwarded-Proto"), -1, 0),
+		forwardedListValue(r.Header.Values("X-Forwarded-Host"), -1, 0),
+	)
+	return info
+}
+
+func (info *ForwardedInfo) applySchemeAndHost(scheme, host string) {
+	scheme = strings.ToLower(scheme)
+	if scheme == "http" || scheme == "https" {
+		info.Scheme = scheme
+	}
+	if host != "" && !strings.ContainsAny(host, "/\\ @") {
+		info.Host = host
+	}
+}
+
+// walkForwardedFor returns the right-most hop that is not a trusted proxy,
+// together with its index. Parsing stops at the first unusable entry, in
+// which case the last trusted address seen is returned.
+func walkForwardedFor(hops []string, peer netip.Addr, trusted []netip.Prefix, parse func(string) (netip.Addr, bool)) (netip.Addr, int) {
+	addr, index := peer, -1
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, ok := parse(hops[i])
+		if !ok {
+			break
+		}
+		addr, index = hop, i
+		if !containsAddr(trusted, addr) {
+			break
+		}
+	}
+	return addr, index
+}
+
+// parseForwardedNode parses an RFC 7239 node or X-Forwarded-For entry:
+// "192.0.2.1", "192.0.2.1:8080", "[2001:db8::1]:8080" or "2001:db8::1".
+// Obfuscated identifiers and "unknown" are rejected.
+func parseForwardedNode(node string) (netip.Addr, bool) {
+	node = strings.Trim(strings.TrimSpace(node), `"`)
+	if addrPort, err := netip.ParseAddrPort(node); err == nil {
+		return addrPort.Addr().Unmap(), true
+	}
+	node = strings.TrimSuffix(strings.TrimPrefix(node, "["), "]")
+	if addr, err := netip.ParseAddr(node); err == nil {
+		return addr.Unmap(), true
+	}
+	return netip.Addr{}, false
+}
+
+// parseForwardedHeader splits Forwarded header values into elements of
+// lower-cased parameter names to unquoted values.
+func parseForwardedHeader(values []string) []map[string]string {
+	var elements []map[string]string
+	for _, value := range values {
+		for _, rawElement := range splitQuoted(value, ',') {
+			element := make(map[string]string)
+			for _, pair := range splitQuoted(rawElement, ';') {
+				name, val, ok := strings.Cut(strings.TrimSpace(pair), "=")
+				if !ok {
+					continue
+				}
+				val = strings.TrimSpace(val)
+				if len(val) >= 2 && val[0] == '"' && val[len(val)-1] == '"' {
+					val = strings.ReplaceAll(val[1:len(val)-1], `\"`, `"`)
+				}
+				element[strings.ToLower(strings.TrimSpace(name))] = val
+			}
+			elements = append(elements, element)
+		}
+	}
+	return elements
+}
+
+func splitQuoted(s string, sep byte) []string {
+	var parts []string
+	quoted, start := false, 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && quoted:
+			i++
+		case s[i] == '"':
+			quoted = !quoted
+		case s[i] == sep && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// forwardedListValue picks the X-Forwarded-Proto or X-Forwarded-Host entry
+// belonging to the resolved X-Forwarded-For hop when both lists line up, and
+// otherwise the right-most entry, which was written by the nearest trusted
+// proxy. Left-most entries are client controlled.
+func forwardedListValue(values []string, index, hops int) string {
+	if len(values) == 0 {
+		return ""
+	}
+	entries := strings.Split(strings.Join(values, ","), ",")
+	if index >= 0 && len(entries) == hops {
+		return strings.TrimSpace(entries[index])
+	}
+	return strings.TrimSpace(entries[len(entries)-1])
+}
+
+func parseRemoteAddr(remoteAddr string) netip.Addr {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	addr, _ := netip.ParseAddr(host)
+	return addr.WithZone("").Unmap()
+}
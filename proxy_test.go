@@ -0,0 +1,145 @@
+package simplerouter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProxyHeaders(t *testing.T) {
+	router := New().Use(ProxyHeaders(ProxyHeadersConfig{
+		TrustedProxies: []string{"10.0.0.0/8", "fd00::/8"},
+	}))
+	router.GET("/", func(w http.ResponseWriter, r *http.Request) {
+		info, _ := ForwardedFrom(r.Context())
+		fmt.Fprintf(w, "%s %s %s", info.ClientIP, info.Scheme, info.Host)
+	})
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		expected   string
+	}{
+		{
+			"untrusted peer ignores headers", "203.0.113.5:4000",
+			map[string]string{"X-Forwarded-For": "198.51.100.1", "X-Forwarded-Proto": "https", "X-Forwarded-Host": "evil.example"},
+			"203.0.113.5 http example.com",
+		},
+		{
+			"x-forwarded headers", "10.0.0.1:4000",
+			map[string]string{"X-Forwarded-For": "198.51.100.1", "X-Forwarded-Proto": "https", "X-Forwarded-Host": "shop.example"},
+			"198.51.100.1 https shop.example",
+		},
+		{
+			"spoofed left-most entry", "10.0.0.1:4000",
+			map[string]string{"X-Forwarded-For": "1.2.3.4, 198.51.100.1, 10.0.0.2"},
+			"198.51.100.1 http example.com",
+		},
+		{
+			"spoofed left-most host", "10.0.0.1:4000",
+			map[string]string{"X-Forwarded-For": "198.51.100.1", "X-Forwarded-Host": "evil.example, real.example", "X-Forwarded-Proto": "http, https"},
+			"198.51.100.1 https real.example",
+		},
+		{
+			"host aligned with resolved hop", "10.0.0.1:4000",
+			map[string]string{"X-Forwarded-For": "1.2.3.4, 198.51.100.1, 10.0.0.2", "X-Forwarded-Host": "evil.example, real.example, internal.lb"},
+			"198.51.100.1 http real.example",
+		},
+		{
+			"forwarded header", "10.0.0.1:4000",
+			map[string]string{"Forwarded": `for=192.0.2.60;proto=https;host="api.example", for=10.0.0.3`},
+			"192.0.2.60 https api.example",
+		},
+		{
+			"forwarded IPv6 with port", "[fd00::1]:4000",
+			map[string]string{"Forwarded": `for="[2001:db8:cafe::17]:4711";proto=https`},
+			"2001:db8:cafe::17 https example.com",
+		},
+		{
+			"forwarded preferred over x-forwarded-for", "10.0.0.1:4000",
+			map[string]string{"Forwarded": "for=192.0.2.60", "X-Forwarded-For": "198.51.100.1"},
+			"192.0.2.60 http example.com",
+		},
+		{
+			"forwarded unknown stops walk", "10.0.0.1:4000",
+			map[string]string{"Forwarded": "for=unknown, for=10.0.0.5"},
+			"10.0.0.5 http example.com",
+		},
+		{
+			"x-real-ip", "10.0.0.1:4000",
+			map[string]string{"X-Real-IP": "198.51.100.9"},
+			"198.51.100.9 http example.com",
+		},
+		{
+			"invalid proto ignored", "10.0.0.1:4000",
+			map[string]string{"X-Forwarded-For": "198.51.100.1", "X-Forwarded-Proto": "javascript"},
+			"198.51.100.1 http example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Body.String() != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestProxyHeadersIntegration(t *testing.T) {
+	var buf bytes.Buffer
+	filter, err := NewIPFilter(IPFilterConfig{Allow: []string{"198.51.100.0/24"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router := New().Use(
+		AccessLogging(AccessLogConfig{Output: &buf, Format: JSONLogFormat}),
+		RealIP("10.0.0.0/8"),
+		filter.Middleware(),
+	)
+	router.GET("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(KeyByIP(r)))
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "10.0.0.1:4000"
+	req.Header.Set("X-Forwarded-For", "198.51.100.7")
+	req.Header.Set("X-Forwarded-Host", "public.example")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK || rr.Body.String() != "198.51.100.7" {
+		t.Fatalf("Expected forwarded client to pass filter and key by its IP, got %d %q", rr.Code, rr.Body.String())
+	}
+
+	var entry AccessLogEntry
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to parse log entry: %v", err)
+	}
+	if entry.RemoteAddr != "198.51.100.7" || entry.Host != "public.example" {
+		t.Errorf("Expected log to record forwarded client, got remote_addr=%q host=%q", entry.RemoteAddr, entry.Host)
+	}
+
+	buf.Reset()
+	req = httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "198.51.100.8:4000"
+	req.Header.Set("X-Forwarded-For", "203.0.113.1")
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || rr.Body.String() != "198.51.100.8" {
+		t.Errorf("Expected header from untrusted peer to be ignored, got %d %q", rr.Code, rr.Body.String())
+	}
+}
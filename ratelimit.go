@@ -0,0 +1,281 @@
+package simplerouter
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"hash/fnv"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type RateLimitAlgorithm int
+
+const (
+	TokenBucket RateLimitAlgorithm = iota
+	SlidingWindow
+)
+
+type RateLimitPolicy struct {
+	Limit     int
+	Window    time.Duration
+	Algorithm RateLimitAlgorithm
+}
+
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+// RateLimitStore applies a policy to a key atomically. Implementations backed
+// by shared storage let several instances enforce a common limit.
+type RateLimitStore interface {
+	Take(ctx context.Context, key string, policy RateLimitPolicy) (RateLimitResult, error)
+}
+
+type RateLimitConfig struct {
+	RateLimitPolicy
+	KeyFunc func(r *http.Request) string
+	Store   RateLimitStore
+
+	// Prefix namespaces keys in Store. Keys always include the policy, so
+	// Groups with different limits can share a Store; set Prefix to keep
+	// Groups with the same policy apart as well.
+	Prefix string
+}
+
+func RateLimit(config RateLimitConfig) Middleware {
+	if config.KeyFunc == nil {
+		config.KeyFunc = KeyByIP
+	}
+	if config.Store == nil {
+		config.Store = NewMemoryRateLimitStore()
+	}
+	if config.Limit <= 0 || config.Window <= 0 {
+		panic("simplerouter: rate limit requires a positive Limit and Window")
+	}
+	policy := strconv.Itoa(config.Limit) + ";w=" + strconv.Itoa(ceilSeconds(config.Window))
+	namespace := config.Prefix + "rl:" + strconv.Itoa(config.Limit) + ":" + config.Window.String() + ":" + strconv.Itoa(int(config.Algorithm)) + ":"
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			key := config.KeyFunc(r)
+			if key == "" {
+				key = KeyByIP(r)
+			}
+
+			result, err := config.Store.Take(r.Context(), namespace+key, config.RateLimitPolicy)
+			if err != nil {
+				// Fail open: an unavailable store should not take the API down.
+				LogError(r, err)
+				next(w, r)
+				return
+			}
+
+			header := w.Header()
+			header.Set("RateLimit-Policy", policy)
+			header.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+			header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+			if !result.Allowed {
+				header.Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+				http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+				return
+			}
+			next(w, r)
+		}
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	if d <= 0 {
+		return 0
+	}
+	return int(math.Ceil(d.Seconds()))
+}
+
+func KeyByIP(r *http.Request) string {
+	return ClientIP(r)
+}
+
+// KeyByHeader keys on a request header. Keys are namespaced by header name
+// so a client cannot send another client's IP and share its bucket.
+func KeyByHeader(name string) func(r *http.Request) string {
+	prefix := "hdr:" + http.CanonicalHeaderKey(name) + ":"
+	return func(r *http.Request) string {
+		if value := r.Header.Get(name); value != "" {
+			return prefix + value
+		}
+		return ""
+	}
+}
+
+// KeyByAPIKey keys on an API key. The key is hashed so a shared Store never
+// holds the secret itself.
+func KeyByAPIKey(header, queryParam string) func(r *http.Request) string {
+	return func(r *http.Request) string {
+		key := r.Header.Get(header)
+		if key == "" && queryParam != "" {
+			key = r.URL.Query().Get(queryParam)
+		}
+		if key == "" {
+			return ""
+		}
+		sum := sha256.Sum256([]byte(key))
+		return "key:" + base64.RawURLEncoding.EncodeToString(sum[:])
+	}
+}
+
+const rateLimitShards = 32
+
+type MemoryRateLimitStore struct {
+	shards        [rateLimitShards]rateLimitShard
+	sweepInterval time.Duration
+	now           func() time.Time
+}
+
+type rateLimitShard struct {
+	mu        sync.Mutex
+	entries   map[string]*rateLimitEntry
+	lastSweep time.Time
+}
+
+type rateLimitEntry struct {
+	tokens      float64
+	last        time.Time
+	windowStart time.Time
+	current     int
+	previous    int
+	expires     time.Time
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	s := &MemoryRateLimitStore{
+		sweepInterval: time.Minute,
+		now:           time.Now,
+	}
+	for i := range s.shards {
+		s.shards[i].entries = make(map[string]*rateLimitEntry)
+	}
+	return s
+}
+
+func (s *MemoryRateLimitStore) Take(ctx context.Context, key string, policy RateLimitPolicy) (RateLimitResult, error) {
+	shard := s.shard(key)
+	now := s.now()
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if now.Sub(shard.lastSweep) >= s.sweepInterval {
+		for k, e := range shard.entries {
+			if now.After(e.expires) {
+				delete(shard.entries, k)
+			}
+		}
+		shard.lastSweep = now
+	}
+
+	entry := shard.entries[key]
+	if entry == nil {
+		entry = &rateLimitEntry{
+			tokens:      float64(policy.Limit),
+			last:        now,
+			windowStart: now,
+		}
+		shard.entries[key] = entry
+	}
+
+	if policy.Algorithm == SlidingWindow {
+		return entry.takeSlidingWindow(policy, now), nil
+	}
+	return entry.takeTokenBucket(policy, now), nil
+}
+
+func (s *MemoryRateLimitStore) shard(key string) *rateLimitShard {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return &s.shards[h.Sum32()%rateLimitShards]
+}
+
+func (e *rateLimitEntry) takeTokenBucket(policy RateLimitPolicy, now time.Time) RateLimitResult {
+	limit := float64(policy.Limit)
+	rate := limit / policy.Window.Seconds()
+
+	e.tokens = math.Min(limit, e.tokens+now.Sub(e.last).Seconds()*rate)
+	e.last = now
+
+	result := RateLimitResult{Limit: policy.Limit}
+	if e.tokens >= 1 {
+		e.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - e.tokens) / rate * float64(time.Second))
+	}
+
+	result.Remaining = int(e.tokens)
+	result.Reset = time.Duration((limit - e.tokens) / rate * float64(time.Second))
+	e.expires = now.Add(result.Reset)
+	return result
+}
+
+// takeSlidingWindow approximates a sliding log by weighting the previous
+// fixed window's count by how much of it still overlaps the sliding window.
+func (e *rateLimitEntry) takeSlidingWindow(policy RateLimitPolicy, now time.Time) RateLimitResult {
+	window := policy.Window
+
+	elapsed := now.Sub(e.windowStart)
+	if elapsed >= window {
+		windows := elapsed / window
+		if windows == 1 {
+			e.previous = e.current
+		} else {
+			e.previous = 0
+		}
+		e.current = 0
+		e.windowStart = e.windowStart.Add(windows * window)
+		elapsed = now.Sub(e.windowStart)
+	}
+
+	fraction := 1 - float64(elapsed)/float64(window)
+	count := float64(e.previous)*fraction + float64(e.current)
+
+	result := RateLimitResult{
+		Limit: policy.Limit,
+		Reset: window - elapsed,
+	}
+	if count+1 <= float64(policy.Limit) {
+		e.current++
+		count++
+		result.Allowed = true
+	} else {
+		result.RetryAfter = e.slidingRetryAfter(policy, elapsed)
+	}
+
+	result.Remaining = max(0, policy.Limit-int(math.Ceil(count)))
+	e.expires = e.windowStart.Add(2 * window)
+	return result
+}
+
+func (e *rateLimitEntry) slidingRetryAfter(policy RateLimitPolicy, elapsed time.Duration) time.Duration {
+	window := float64(policy.Window)
+	limit := float64(policy.Limit)
+
+	if e.current+1 <= policy.Limit && e.previous > 0 {
+		// Wait until enough of the previous window has slid out.
+		wait := window*(1-(limit-float64(e.current)-1)/float64(e.previous)) - float64(elapsed)
+		return time.Duration(math.Max(wait, 0))
+	}
+
+	// The current window alone is full; wait for it to become the previous
+	// window and decay enough to admit one more request.
+	wait := (window - float64(elapsed)) + window*(1-(limit-1)/float64(e.current))
+	return time.Duration(math.Max(wait, 0))
+}
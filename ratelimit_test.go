@@ -0,0 +1,214 @@
+package simplerouter
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+type failingStore struct{}
+
+func (failingStore) Take(ctx context.Context, key string, policy RateLimitPolicy) (RateLimitResult, error) {
+	return RateLimitResult{}, errors.New("store unavailable")
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	router := New()
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}
+
+	router.Group("/api").Use(RateLimit(RateLimitConfig{
+		RateLimitPolicy: RateLimitPolicy{Limit: 2, Window: time.Minute},
+	})).GET("/items", handler)
+
+	router.Group("/partner").Use(RateLimit(RateLimitConfig{
+		RateLimitPolicy: RateLimitPolicy{Limit: 1, Window: time.Minute, Algorithm: SlidingWindow},
+		KeyFunc:         KeyByAPIKey("X-Api-Key", "api_key"),
+	})).GET("/items", handler)
+
+	send := func(path, remoteAddr, apiKey string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", path, nil)
+		req.RemoteAddr = remoteAddr
+		if apiKey != "" {
+			req.Header.Set("X-Api-Key", apiKey)
+		}
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	for i := 0; i < 2; i++ {
+		if rr := send("/api/items", "10.0.0.1:1234", ""); rr.Code != http.StatusOK {
+			t.Fatalf("Expected request %d to be allowed, got %d", i+1, rr.Code)
+		}
+	}
+
+	rr := send("/api/items", "10.0.0.1:5678", "")
+	if rr.Code != http.StatusTooManyRequests {
+		t.Fatalf("Expected status %d, got %d", http.StatusTooManyRequests, rr.Code)
+	}
+	if rr.Header().Get("Retry-After") != "30" {
+		t.Errorf("Expected Retry-After of 30 seconds, got %q", rr.Header().Get("Retry-After"))
+	}
+	if rr.Header().Get("RateLimit-Limit") != "2" || rr.Header().Get("RateLimit-Remaining") != "0" {
+		t.Errorf("Unexpected rate limit headers %v", rr.Header())
+	}
+	if rr.Header().Get("RateLimit-Policy") != "2;w=60" {
+		t.Errorf("Expected RateLimit-Policy %q, got %q", "2;w=60", rr.Header().Get("RateLimit-Policy"))
+	}
+
+	if rr := send("/api/items", "10.0.0.2:1234", ""); rr.Code != http.StatusOK {
+		t.Errorf("Expected a different client to be allowed, got %d", rr.Code)
+	}
+
+	if rr := send("/partner/items", "10.0.0.1:1234", "key-a"); rr.Code != http.StatusOK {
+		t.Errorf("Expected group with its own limit to allow the request, got %d", rr.Code)
+	}
+	if rr := send("/partner/items", "10.0.0.9:1234", "key-a"); rr.Code != http.StatusTooManyRequests {
+		t.Errorf("Expected same API key from another IP to be limited, got %d", rr.Code)
+	}
+	if rr := send("/partner/items", "10.0.0.1:1234", "key-b"); rr.Code != http.StatusOK {
+		t.Errorf("Expected a different API key to be allowed, got %d", rr.Code)
+	}
+}
+
+func TestRateLimitFailsOpen(t *testing.T) {
+	router := New()
+	router.GET("/test", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}, RateLimit(RateLimitConfig{
+		RateLimitPolicy: RateLimitPolicy{Limit: 1, Window: time.Second},
+		Store:           failingStore{},
+	}))
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/test", nil))
+	if rr.Code != http.StatusOK {
+		t.Errorf("Expected request to pass when the store fails, got %d", rr.Code)
+	}
+}
+
+func TestMemoryRateLimitStoreTokenBucket(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	policy := RateLimitPolicy{Limit: 10, Window: 10 * time.Second}
+
+	for i := 0; i < 10; i++ {
+		if result, _ := store.Take(context.Background(), "k", policy); !result.Allowed {
+			t.Fatalf("Expected request %d to be allowed", i+1)
+		}
+	}
+	result, _ := store.Take(context.Background(), "k", policy)
+	if result.Allowed || result.RetryAfter != time.Second {
+		t.Fatalf("Expected denial with 1s retry, got %+v", result)
+	}
+
+	now = now.Add(time.Second)
+	if result, _ := store.Take(context.Background(), "k", policy); !result.Allowed {
+		t.Error("Expected a refilled token after one second")
+	}
+}
+
+func TestMemoryRateLimitStoreSlidingWindow(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	policy := RateLimitPolicy{Limit: 4, Window: 10 * time.Second, Algorithm: SlidingWindow}
+
+	for i := 0; i < 4; i++ {
+		if result, _ := store.Take(context.Background(), "k", policy); !result.Allowed {
+			t.Fatalf("Expected request %d to be allowed", i+1)
+		}
+	}
+	if result, _ := store.Take(context.Background(), "k", policy); result.Allowed {
+		t.Fatal("Expected fifth request in the window to be denied")
+	}
+
+	// Halfway into the next window the previous window still weighs 2.
+	now = now.Add(15 * time.Second)
+	allowed := 0
+	for i := 0; i < 4; i++ {
+		if result, _ := store.Take(context.Background(), "k", policy); result.Allowed {
+			allowed++
+		}
+	}
+	if allowed != 2 {
+		t.Errorf("Expected 2 requests allowed by the sliding window, got %d", allowed)
+	}
+}
+
+func TestMemoryRateLimitStoreEviction(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	policy := RateLimitPolicy{Limit: 1, Window: time.Second}
+
+	other := "b"
+	for i := 0; store.shard(other) != store.shard("a"); i++ {
+		other = "b" + strconv.Itoa(i)
+	}
+
+	store.Take(context.Background(), "a", policy)
+	now = now.Add(2 * time.Minute)
+	store.Take(context.Background(), other, policy)
+
+	entries := store.shard("a").entries
+	if _, ok := entries["a"]; ok || len(entries) != 1 {
+		t.Errorf("Expected expired entry to be evicted, got %d entries", len(entries))
+	}
+}
+
+func TestKeyByHeaderNamespaced(t *testing.T) {
+	keyFunc := KeyByHeader("X-Tenant")
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.0.2.10:1234"
+	req.Header.Set("X-Tenant", "192.0.2.10")
+	if key := keyFunc(req); key == KeyByIP(req) {
+		t.Errorf("Expected header key to be distinct from the IP key space, got %q", key)
+	}
+
+	req.Header.Del("X-Tenant")
+	if key := keyFunc(req); key != "" {
+		t.Errorf("Expected empty key without the header so the IP fallback applies, got %q", key)
+	}
+}
+
+func TestRateLimitSharedStore(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	router := New()
+	handler := func(w http.ResponseWriter, r *http.Request) {}
+
+	router.Group("/strict").Use(RateLimit(RateLimitConfig{
+		RateLimitPolicy: RateLimitPolicy{Limit: 1, Window: time.Hour},
+		Store:           store,
+	})).GET("/items", handler)
+	router.Group("/loose").Use(RateLimit(RateLimitConfig{
+		RateLimitPolicy: RateLimitPolicy{Limit: 100, Window: time.Minute},
+		Store:           store,
+	})).GET("/items", handler)
+
+	for _, path := range []string{"/strict/items", "/loose/items", "/loose/items"} {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("Expected %s to be allowed, got %d", path, rr.Code)
+		}
+	}
+}
+
+func TestKeyByAPIKeyHashed(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Api-Key", "secret-key")
+	key := KeyByAPIKey("X-Api-Key", "")(req)
+	if key == "" || strings.Contains(key, "secret-key") {
+		t.Errorf("Expected a hashed key, got %q", key)
+	}
+}
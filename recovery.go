@@ -0,0 +1,94 @@
+package simplerouter
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime/debug"
+)
+
+type RecoveryConfig struct {
+	Logger      *slog.Logger
+	OnPanic     func(r *http.Request, recovered any, stack []byte)
+	ProblemJSON bool
+}
+
+type problemDetails struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+func Recovery(config RecoveryConfig) Middleware {
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			wrapped, ww := wrapResponseWriter(w)
+			// Headers set by outer middleware, such as security headers,
+			// are kept on the error response; the handler's are not.
+			outer := w.Header().Clone()
+
+			defer func() {
+				recovered := recover()
+				if recovered == nil {
+					return
+				}
+				// ErrAbortHandler is net/http's signal to abort the response
+				// silently and must reach the server untouched.
+				if recovered == http.ErrAbortHandler {
+					panic(recovered)
+				}
+
+				stack := debug.Stack()
+				err := fmt.Errorf("panic: %v", recovered)
+				markPanic(r, err)
+
+				logger := config.Logger
+				if logger == nil {
+					logger = slog.Default()
+				}
+				logger.ErrorContext(r.Context(), "panic recovered",
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.Path),
+					slog.Any("panic", recovered),
+					slog.String("stack", string(stack)),
+				)
+
+				if config.OnPanic != nil {
+					config.OnPanic(r, recovered, stack)
+				}
+
+				// Once headers are out the client would read a truncated
+				// body as complete; abort the connection instead.
+				if wrapped.wroteHeader {
+					panic(http.ErrAbortHandler)
+				}
+				header := wrapped.Header()
+				clear(header)
+				for key, values := range outer {
+					header[key] = values
+				}
+				writeInternalError(wrapped, config.ProblemJSON)
+			}()
+
+			next(ww, r)
+		}
+	}
+}
+
+func writeInternalError(w http.ResponseWriter, problemJSON bool) {
+	if !problemJSON {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusInternalServerError)
+	json.NewEncoder(w).Encode(problemDetails{
+		Type:   "about:blank",
+		Title:  http.StatusText(http.StatusInternalServerError),
+		Status: http.StatusInternalServerError,
+	})
+}
@@ -0,0 +1,195 @@
+package simplerouter
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecovery(t *testing.T) {
+	var logs bytes.Buffer
+	var reported any
+	var reportedStack []byte
+
+	router := New().Use(Recovery(RecoveryConfig{
+		Logger: slog.New(slog.NewTextHandler(&logs, nil)),
+		OnPanic: func(r *http.Request, recovered any, stack []byte) {
+			reported = recovered
+			reportedStack = stack
+		},
+	}))
+
+	router.GET("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("something broke")
+	})
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/panic", nil))
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Errorf("Expected status %d, got %d", http.StatusInternalServerError, rr.Code)
+	}
+	if reported != "something broke" {
+		t.Errorf("Expected OnPanic to receive the panic value, got %v", reported)
+	}
+	if !bytes.Contains(reportedStack, []byte("recovery_test.go")) {
+		t.Error("Expected stack to include the panicking handler")
+	}
+	if !strings.Contains(logs.String(), "panic recovered") || !strings.Contains(logs.String(), "something broke") {
+		t.Errorf("Expected panic to be logged, got %q", logs.String())
+	}
+}
+
+func TestRecoveryProblemJSON(t *testing.T) {
+	router := New().Use(Recovery(RecoveryConfig{
+		Logger:      slog.New(slog.NewTextHandler(io.Discard, nil)),
+		ProblemJSON: true,
+	}))
+
+	router.GET("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/panic", nil))
+
+	if ct := rr.Header().Get("Content-Type"); ct != "application/problem+json" {
+		t.Errorf("Expected problem+json content type, got %q", ct)
+	}
+	var problem problemDetails
+	if err := json.Unmarshal(rr.Body.Bytes(), &problem); err != nil {
+		t.Fatalf("Failed to decode problem: %v", err)
+	}
+	if problem.Status != http.StatusInternalServerError {
+		t.Errorf("Expected problem status %d, got %d", http.StatusInternalServerError, problem.Status)
+	}
+}
+
+func TestRecoveryHeadersAlreadySent(t *testing.T) {
+	var reported any
+	router := New().Use(Recovery(RecoveryConfig{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+		OnPanic: func(r *http.Request, recovered any, stack []byte) {
+			reported = recovered
+		},
+	}))
+
+	router.GET("/partial", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("partial"))
+		panic("late failure")
+	})
+
+	rr := httptest.NewRecorder()
+	func() {
+		defer func() {
+			if p := recover(); p != http.ErrAbortHandler {
+				t.Errorf("Expected http.ErrAbortHandler to abort the connection, got %v", p)
+			}
+		}()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/partial", nil))
+	}()
+
+	if reported != "late failure" {
+		t.Errorf("Expected panic to be reported before aborting, got %v", reported)
+	}
+	if rr.Body.String() != "partial" {
+		t.Errorf("Expected no error body after headers were sent, got %q", rr.Body.String())
+	}
+}
+
+func TestRecoveryAbortHandler(t *testing.T) {
+	router := New().Use(Recovery(RecoveryConfig{
+		Logger: slog.New(slog.NewTextHandler(io.Discard, nil)),
+	}))
+
+	router.GET("/abort", func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	})
+
+	defer func() {
+		if recovered := recover(); recovered != http.ErrAbortHandler {
+			t.Errorf("Expected http.ErrAbortHandler to be re-panicked, got %v", recovered)
+		}
+	}()
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/abort", nil))
+}
+
+func TestRecoveryMarksAccessLog(t *testing.T) {
+	recovery := Recovery(RecoveryConfig{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))})
+
+	tests := []struct {
+		name          string
+		recoveryFirst bool
+	}{
+		{"recovery inside access logging", false},
+		{"recovery outside access logging", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			accessLog := AccessLogging(AccessLogConfig{Output: &buf, Format: JSONLogFormat})
+
+			router := New()
+			if tt.recoveryFirst {
+				router = router.Use(recovery, accessLog)
+			} else {
+				router = router.Use(accessLog, recovery)
+			}
+			router.GET("/panic", func(w http.ResponseWriter, r *http.Request) {
+				panic("boom")
+			})
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("GET", "/panic", nil))
+
+			var entry AccessLogEntry
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("Failed to decode log entry: %v", err)
+			}
+			if !entry.Panic {
+				t.Error("Expected access log entry to be marked as a panic")
+			}
+			if entry.Status != http.StatusInternalServerError {
+				t.Errorf("Expected logged status %d, got %d", http.StatusInternalServerError, entry.Status)
+			}
+			if rr.Code != http.StatusInternalServerError {
+				t.Errorf("Expected response status %d, got %d", http.StatusInternalServerError, rr.Code)
+			}
+		})
+	}
+}
+
+func TestRecoveryDiscardsHandlerHeaders(t *testing.T) {
+	router := New().Use(
+		SecureHeaders(APISecureHeaders()),
+		Recovery(RecoveryConfig{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}),
+	)
+	router.GET("/panic", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session=abc")
+		w.Header().Set("Cache-Control", "public, max-age=3600")
+		w.Header().Set("ETag", `"v1"`)
+		panic("boom")
+	})
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/panic", nil))
+
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("Expected status %d, got %d", http.StatusInternalServerError, rr.Code)
+	}
+	for _, name := range []string{"Set-Cookie", "Cache-Control", "ETag"} {
+		if got := rr.Header().Get(name); got != "" {
+			t.Errorf("Expected handler's %s to be dropped, got %q", name, got)
+		}
+	}
+	if rr.Header().Get("X-Frame-Options") != "DENY" {
+		t.Error("Expected security headers from outer middleware to be kept")
+	}
+}
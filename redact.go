@@ -0,0 +1,198 @@
+package simplerouter
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const defaultRedactionMask = "[REDACTED]"
+
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+type RedactionConfig struct {
+	Headers     []string
+	QueryParams []string
+	MaskEmails  bool
+	Patterns    []*regexp.Regexp
+	Mask        string
+
+	jsonFields *regexp.Regexp
+}
+
+func DefaultRedaction() *RedactionConfig {
+	return &RedactionConfig{
+		Headers: []string{
+			"Authorization",
+			"Proxy-Authorization",
+			"Cookie",
+			"Set-Cookie",
+			"X-Api-Key",
+			"X-Csrf-Token",
+		},
+		QueryParams: []string{
+			"token",
+			"access_token",
+			"refresh_token",
+			"id_token",
+			"api_key",
+			"apikey",
+			"password",
+			"secret",
+			"code",
+			"signature",
+		},
+		MaskEmails: true,
+	}
+}
+
+// compile returns a copy of c with its body redaction pattern built once,
+// so it is not recompiled for every logged request.
+func (c *RedactionConfig) compile() *RedactionConfig {
+	compiled := *c
+	compiled.jsonFields = jsonFieldPattern(c.QueryParams)
+	return &compiled
+}
+
+// jsonFieldPattern matches string values of the named JSON fields. The
+// closing quote is optional so values cut off by truncation are still
+// masked.
+func jsonFieldPattern(names []string) *regexp.Regexp {
+	if len(names) == 0 {
+		return nil
+	}
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = regexp.QuoteMeta(name)
+	}
+	return regexp.MustCompile(`(?i)("(?:` + strings.Join(quoted, "|") + `)"\s*:\s*)"(?:[^"\\]|\\.)*(?:"|$)`)
+}
+
+func (c *RedactionConfig) mask() string {
+	if c.Mask != "" {
+		return c.Mask
+	}
+	return defaultRedactionMask
+}
+
+func (c *RedactionConfig) sensitiveHeader(name string) bool {
+	for _, h := range c.Headers {
+		if strings.EqualFold(h, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *RedactionConfig) sensitiveParam(name string) bool {
+	for _, p := range c.QueryParams {
+		if strings.EqualFold(p, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *RedactionConfig) redactString(s string) string {
+	if s == "" {
+		return s
+	}
+	if c.MaskEmails {
+		s = emailPattern.ReplaceAllString(s, c.mask())
+	}
+	for _, pattern := range c.Patterns {
+		s = pattern.ReplaceAllString(s, c.mask())
+	}
+	return s
+}
+
+// redactQuery masks sensitive parameter values while preserving the order
+// and encoding of the remaining raw query.
+func (c *RedactionConfig) redactQuery(rawQuery string) string {
+	if rawQuery == "" {
+		return rawQuery
+	}
+
+	pairs := strings.Split(rawQuery, "&")
+	for i, pair := range pairs {
+		key, _, found := strings.Cut(pair, "=")
+		name, err := url.QueryUnescape(key)
+		if err != nil {
+			name = key
+		}
+		if found && c.sensitiveParam(name) {
+			pairs[i] = key + "=" + url.QueryEscape(c.mask())
+		}
+	}
+	return c.redactString(strings.Join(pairs, "&"))
+}
+
+func (c *RedactionConfig) redactURL(raw string) string {
+	if raw == "" {
+		return raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return c.redactString(raw)
+	}
+	if u.User != nil {
+		u.User = url.User(u.User.Username())
+	}
+	u.RawQuery = c.redactQuery(u.RawQuery)
+	return c.redactString(u.String())
+}
+
+func (c *RedactionConfig) redactHeaders(headers map[string]string) {
+	for name, value := range headers {
+		if c.sensitiveHeader(name) {
+			headers[name] = c.mask()
+		} else {
+			headers[name] = c.redactString(value)
+		}
+	}
+}
+
+func (c *RedactionConfig) redactEntry(entry *AccessLogEntry) {
+	entry.Path = c.redactString(entry.Path)
+	entry.Query = c.redactQuery(entry.Query)
+	entry.Referer = c.redactURL(entry.Referer)
+	entry.UserAgent = c.redactString(entry.UserAgent)
+	entry.Error = c.redactString(entry.Error)
+
+	for name, value := range entry.QueryParams {
+		if c.sensitiveParam(name) {
+			entry.QueryParams[name] = c.mask()
+		} else {
+			entry.QueryParams[name] = c.redactString(value)
+		}
+	}
+	c.redactHeaders(entry.RequestHeaders)
+	c.redactHeaders(entry.ResponseHeaders)
+}
+
+func captureHeaders(header http.Header, names []string) map[string]string {
+	if len(names) == 0 {
+		return nil
+	}
+	captured := make(map[string]string, len(names))
+	for _, name := range names {
+		if values := header.Values(name); len(values) > 0 {
+			captured[http.CanonicalHeaderKey(name)] = strings.Join(values, ", ")
+		}
+	}
+	return captured
+}
+
+func captureQueryParams(query url.Values, names []string) map[string]string {
+	if len(names) == 0 {
+		return nil
+	}
+	captured := make(map[string]string, len(names))
+	for _, name := range names {
+		if values, ok := query[name]; ok {
+			captured[name] = strings.Join(values, ",")
+		}
+	}
+	return captured
+}
@@ -0,0 +1,105 @@
+package simplerouter
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestRequestID(t *testing.T) {
+	router := New().Use(RequestID(RequestIDConfig{MaxLength: 16}))
+
+	var seen string
+	router.GET("/test", func(w http.ResponseWriter, r *http.Request) {
+		seen = RequestIDFrom(r.Context())
+	})
+
+	uuidPattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+	tests := []struct {
+		name     string
+		inbound  string
+		expectID string
+	}{
+		{"generated when missing", "", ""},
+		{"inbound accepted", "abc-123", "abc-123"},
+		{"inbound too long", strings.Repeat("a", 17), ""},
+		{"inbound with invalid characters", "bad id\n", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/test", nil)
+			if tt.inbound != "" {
+				req.Header.Set("X-Request-Id", tt.inbound)
+			}
+			rr := httptest.NewRecorder()
+
+			router.ServeHTTP(rr, req)
+
+			if rr.Header().Get("X-Request-Id") != seen {
+				t.Errorf("Expected response header %q to match context %q", rr.Header().Get("X-Request-Id"), seen)
+			}
+			if tt.expectID != "" && seen != tt.expectID {
+				t.Errorf("Expected request ID %q, got %q", tt.expectID, seen)
+			}
+			if tt.expectID == "" && !uuidPattern.MatchString(seen) {
+				t.Errorf("Expected generated UUIDv7, got %q", seen)
+			}
+		})
+	}
+}
+
+func TestNewULID(t *testing.T) {
+	a, b := NewULID(), NewULID()
+	if len(a) != 26 || strings.Trim(a, crockfordAlphabet) != "" {
+		t.Errorf("Expected 26 character Crockford base32 ULID, got %q", a)
+	}
+	if a == b {
+		t.Error("Expected distinct ULIDs")
+	}
+	if a[:10] > b[:10] {
+		t.Errorf("Expected ULID timestamps to be ordered, got %q then %q", a, b)
+	}
+}
+
+func TestRequestIDInAccessLog(t *testing.T) {
+	tests := []struct {
+		name           string
+		requestIDFirst bool
+	}{
+		{"request id outside access logging", true},
+		{"request id inside access logging", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			accessLog := AccessLogging(AccessLogConfig{Output: &buf, Format: JSONLogFormat})
+			requestID := RequestID(RequestIDConfig{Generator: NewULID})
+
+			router := New()
+			if tt.requestIDFirst {
+				router = router.Use(requestID, accessLog)
+			} else {
+				router = router.Use(accessLog, requestID)
+			}
+			router.GET("/test", func(w http.ResponseWriter, r *http.Request) {})
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("GET", "/test", nil))
+
+			var entry AccessLogEntry
+			if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+				t.Fatalf("Failed to decode log entry: %v", err)
+			}
+			if entry.RequestID == "" || entry.RequestID != rr.Header().Get("X-Request-Id") {
+				t.Errorf("Expected logged request ID %q, got %q", rr.Header().Get("X-Request-Id"), entry.RequestID)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+package simplerouter
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+)
+
+// WrapResponseWriter records the status and size of a response. The value
+// returned by NewWrapResponseWriter only implements http.Flusher,
+// http.Hijacker, http.Pusher and io.ReaderFrom when the wrapped writer does.
+type WrapResponseWriter interface {
+	http.ResponseWriter
+	Status() int
+	BytesWritten() int
+	Unwrap() http.ResponseWriter
+	Tee(io.Writer)
+	OnBeforeWrite(func(status int))
+}
+
+type wrapWriter struct {
+	http.ResponseWriter
+	status      int
+	size        int
+	wroteHeader bool
+	err         error
+	tee         io.Writer
+	beforeWrite []func(status int)
+}
+
+func NewWrapResponseWriter(w http.ResponseWriter) WrapResponseWriter {
+	_, ww := wrapResponseWriter(w)
+	return ww
+}
+
+func wrapResponseWriter(w http.ResponseWriter) (*wrapWriter, WrapResponseWriter) {
+	b := &wrapWriter{ResponseWriter: w}
+
+	_, isFlusher := w.(http.Flusher)
+	_, isHijacker := w.(http.Hijacker)
+	_, isPusher := w.(http.Pusher)
+	_, isReaderFrom := w.(io.ReaderFrom)
+
+	f, h, p, rf := flusher{b}, hijacker{b}, pusher{b}, readerFrom{b}
+
+	switch {
+	case isFlusher && isHijacker && isPusher && isReaderFrom:
+		return b, struct {
+			*wrapWriter
+			flusher
+			hijacker
+			pusher
+			readerFrom
+		}{b, f, h, p, rf}
+	case isFlusher && isHijacker && isPusher:
+		return b, struct {
+			*wrapWriter
+			flusher
+			hijacker
+			pusher
+		}{b, f, h, p}
+	case isFlusher && isHijacker && isReaderFrom:
+		return b, struct {
+			*wrapWriter
+			flusher
+			hijacker
+			readerFrom
+		}{b, f, h, rf}
+	case isFlusher && isPusher && isReaderFrom:
+		return b, struct {
+			*wrapWriter
+			flusher
+			pusher
+			readerFrom
+		}{b, f, p, rf}
+	case isHijacker && isPusher && isReaderFrom:
+		return b, struct {
+			*wrapWriter
+			hijacker
+			pusher
+			readerFrom
+		}{b, h, p, rf}
+	case isFlusher && isHijacker:
+		return b, struct {
+			*wrapWriter
+			flusher
+			hijacker
+		}{b, f, h}
+	case isFlusher && isPusher:
+		return b, struct {
+			*wrapWriter
+			flusher
+			pusher
+		}{b, f, p}
+	case isFlusher && isReaderFrom:
+		return b, struct {
+			*wrapWriter
+			flusher
+			readerFrom
+		}{b, f, rf}
+	case isHijacker && isPusher:
+		return b, struct {
+			*wrapWriter
+			hijacker
+			pusher
+		}{b, h, p}
+	case isHijacker && isReaderFrom:
+		return b, struct {
+			*wrapWriter
+			hijacker
+			readerFrom
+		}{b, h, rf}
+	case isPusher && isReaderFrom:
+		return b, struct {
+			*wrapWriter
+			pusher
+			readerFrom
+		}{b, p, rf}
+	case isFlusher:
+		return b, struct {
+			*wrapWriter
+			flusher
+		}{b, f}
+	case isHijacker:
+		return b, struct {
+			*wrapWriter
+			hijacker
+		}{b, h}
+	case isPusher:
+		return b, struct {
+			*wrapWriter
+			pusher
+		}{b, p}
+	case isReaderFrom:
+		return b, struct {
+			*wrapWriter
+			readerFrom
+		}{b, rf}
+	default:
+		return b, b
+	}
+}
+
+func (w *wrapWriter) Status() int {
+	return w.status
+}
+
+func (w *wrapWriter) BytesWritten() int {
+	return w.size
+}
+
+func (w *wrapWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *wrapWriter) Tee(tee io.Writer) {
+	w.tee = tee
+}
+
+func (w *wrapWriter) OnBeforeWrite(fn func(status int)) {
+	w.beforeWrite = append(w.beforeWrite, fn)
+}
+
+func (w *wrapWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	// Informational responses may precede the final status.
+	if status >= 100 && status < 200 && status != http.StatusSwitchingProtocols {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+
+	w.wroteHeader = true
+	w.status = status
+	for _, fn := range w.beforeWrite {
+		fn(status)
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *wrapWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.size += n
+	if w.tee != nil {
+		w.tee.Write(b[:n])
+	}
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	return n, err
+}
+
+type flusher struct{ w *wrapWriter }
+
+func (f flusher) Flush() {
+	if !f.w.wroteHeader {
+		f.w.WriteHeader(http.StatusOK)
+	}
+	f.w.ResponseWriter.(http.Flusher).Flush()
+}
+
+type hijacker struct{ w *wrapWriter }
+
+func (h hijacker) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return h.w.ResponseWriter.(http.Hijacker).Hijack()
+}
+
+type pusher struct{ w *wrapWriter }
+
+func (p pusher) Push(target string, opts *http.PushOptions) error {
+	return p.w.ResponseWriter.(http.Pusher).Push(target, opts)
+}
+
+type readerFrom struct{ w *wrapWriter }
+
+func (rf readerFrom) ReadFrom(src io.Reader) (int64, error) {
+	// Fall back to Write so teed output still sees every byte.
+	if rf.w.tee != nil {
+		return io.Copy(struct{ io.Writer }{rf.w}, src)
+	}
+	if !rf.w.wroteHeader {
+		rf.w.WriteHeader(http.StatusOK)
+	}
+	n, err := rf.w.ResponseWriter.(io.ReaderFrom).ReadFrom(src)
+	rf.w.size += int(n)
+	if err != nil && rf.w.err == nil {
+		rf.w.err = err
+	}
+	return n, err
+}
@@ -0,0 +1,129 @@
+package simplerouter
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type readerFromRecorder struct {
+	*httptest.ResponseRecorder
+	readFrom bool
+}
+
+func (r *readerFromRecorder) ReadFrom(src io.Reader) (int64, error) {
+	r.readFrom = true
+	return io.Copy(r.ResponseRecorder, src)
+}
+
+type hijackOnlyWriter struct {
+	http.ResponseWriter
+}
+
+func (hijackOnlyWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, nil
+}
+
+func TestWrapResponseWriterInterfaces(t *testing.T) {
+	tests := []struct {
+		name         string
+		writer       http.ResponseWriter
+		expectFlush  bool
+		expectHijack bool
+		expectRead   bool
+	}{
+		{"recorder", httptest.NewRecorder(), true, false, false},
+		{"reader from", &readerFromRecorder{ResponseRecorder: httptest.NewRecorder()}, true, false, true},
+		{"hijack only", hijackOnlyWriter{httptest.NewRecorder()}, false, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ww := NewWrapResponseWriter(tt.writer)
+
+			if _, ok := ww.(http.Flusher); ok != tt.expectFlush {
+				t.Errorf("Expected http.Flusher=%v, got %v", tt.expectFlush, ok)
+			}
+			if _, ok := ww.(http.Hijacker); ok != tt.expectHijack {
+				t.Errorf("Expected http.Hijacker=%v, got %v", tt.expectHijack, ok)
+			}
+			if _, ok := ww.(io.ReaderFrom); ok != tt.expectRead {
+				t.Errorf("Expected io.ReaderFrom=%v, got %v", tt.expectRead, ok)
+			}
+			if _, ok := ww.(http.Pusher); ok {
+				t.Error("Expected http.Pusher not to be advertised")
+			}
+			if ww.Unwrap() != tt.writer {
+				t.Error("Expected Unwrap to return the inner writer")
+			}
+		})
+	}
+}
+
+func TestWrapResponseWriterTracking(t *testing.T) {
+	rr := httptest.NewRecorder()
+	ww := NewWrapResponseWriter(rr)
+
+	var hookStatus int
+	ww.OnBeforeWrite(func(status int) {
+		hookStatus = status
+		ww.Header().Set("X-Hook", "ran")
+	})
+
+	var tee bytes.Buffer
+	ww.Tee(&tee)
+
+	ww.WriteHeader(http.StatusAccepted)
+	ww.WriteHeader(http.StatusInternalServerError)
+	ww.Write([]byte("hello"))
+
+	if ww.Status() != http.StatusAccepted {
+		t.Errorf("Expected status %d, got %d", http.StatusAccepted, ww.Status())
+	}
+	if hookStatus != http.StatusAccepted {
+		t.Errorf("Expected hook to see status %d, got %d", http.StatusAccepted, hookStatus)
+	}
+	if rr.Header().Get("X-Hook") != "ran" {
+		t.Error("Expected hook to run before headers were sent")
+	}
+	if ww.BytesWritten() != 5 {
+		t.Errorf("Expected 5 bytes written, got %d", ww.BytesWritten())
+	}
+	if tee.String() != "hello" {
+		t.Errorf("Expected tee to receive %q, got %q", "hello", tee.String())
+	}
+}
+
+func TestWrapResponseWriterReadFrom(t *testing.T) {
+	rec := &readerFromRecorder{ResponseRecorder: httptest.NewRecorder()}
+	ww := NewWrapResponseWriter(rec)
+
+	n, err := ww.(io.ReaderFrom).ReadFrom(strings.NewReader("streamed body"))
+	if err != nil {
+		t.Fatalf("ReadFrom returned error: %v", err)
+	}
+	if !rec.readFrom {
+		t.Error("Expected ReadFrom to be delegated to the inner writer")
+	}
+	if int(n) != ww.BytesWritten() || ww.Status() != http.StatusOK {
+		t.Errorf("Expected %d bytes with status 200, got %d bytes with status %d", n, ww.BytesWritten(), ww.Status())
+	}
+}
+
+func TestWrapResponseWriterResponseController(t *testing.T) {
+	rr := httptest.NewRecorder()
+	ww := NewWrapResponseWriter(rr)
+
+	ww.Write([]byte("data"))
+	if err := http.NewResponseController(ww).Flush(); err != nil {
+		t.Fatalf("Flush via ResponseController returned error: %v", err)
+	}
+	if !rr.Flushed {
+		t.Error("Expected inner writer to be flushed")
+	}
+}
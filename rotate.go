@@ -0,0 +1,352 @@
+package simplerouter
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const backupTimeFormat = "20060102-150405.000"
+
+type RotatingFileConfig struct {
+	Filename       string
+	MaxSize        int64
+	Daily          bool
+	MaxBackups     int
+	Compress       bool
+	ReopenOnSIGHUP bool
+}
+
+type RotatingFile struct {
+	config   RotatingFileConfig
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+	broken   bool
+	now      func() time.Time
+	signals  chan os.Signal
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+func NewRotatingFile(config RotatingFileConfig) (*RotatingFile, error) {
+	if config.Filename == "" {
+		return nil, errors.New("simplerouter: rotating file requires a filename")
+	}
+
+	f := &RotatingFile{
+		config: config,
+		now:    time.Now,
+		stop:   make(chan struct{}),
+	}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	if config.ReopenOnSIGHUP {
+		f.signals = make(chan os.Signal, 1)
+		signal.Notify(f.signals, syscall.SIGHUP)
+		f.wg.Add(1)
+		go f.watchSignals()
+	}
+
+	return f, nil
+}
+
+func (f *RotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	if f.broken {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+		f.broken = false
+	}
+
+	if f.shouldRotate(int64(len(p))) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+func (f *RotatingFile) Rotate() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return os.ErrClosed
+	}
+	return f.rotate()
+}
+
+// Reopen closes and reopens the log file under its configured name, for use
+// after an external tool such as logrotate has moved it.
+func (f *RotatingFile) Reopen() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return os.ErrClosed
+	}
+	previous := f.file
+	if err := f.open(); err != nil {
+		return err
+	}
+	f.broken = false
+	return previous.Close()
+}
+
+func (f *RotatingFile) Close() error {
+	f.mu.Lock()
+	if f.file == nil {
+		f.mu.Unlock()
+		return os.ErrClosed
+	}
+	err := f.file.Close()
+	f.file = nil
+	f.mu.Unlock()
+
+	if f.signals != nil {
+		signal.Stop(f.signals)
+	}
+	close(f.stop)
+	f.wg.Wait()
+	return err
+}
+
+func (f *RotatingFile) watchSignals() {
+	defer f.wg.Done()
+	for {
+		select {
+		case <-f.signals:
+			f.Reopen()
+		case <-f.stop:
+			return
+		}
+	}
+}
+
+func (f *RotatingFile) open() error {
+	if err := os.MkdirAll(filepath.Dir(f.config.Filename), 0o755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(f.config.Filename, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	f.file = file
+	f.size = info.Size()
+	// A file left over from an earlier run belongs to the day it was last
+	// written, so daily rotation still happens after a restart.
+	f.openedAt = f.now()
+	if info.Size() > 0 {
+		f.openedAt = info.ModTime()
+	}
+	return nil
+}
+
+func (f *RotatingFile) shouldRotate(n int64) bool {
+	if f.config.MaxSize > 0 && f.size > 0 && f.size+n > f.config.MaxSize {
+		return true
+	}
+	if f.config.Daily {
+		y1, m1, d1 := f.openedAt.Date()
+		y2, m2, d2 := f.now().Date()
+		return y1 != y2 || m1 != m2 || d1 != d2
+	}
+	return false
+}
+
+func (f *RotatingFile) rotate() error {
+	backup, err := f.backupName()
+	if err != nil {
+		return err
+	}
+	if err := f.moveToBackup(backup); err != nil {
+		// Keep writing to the original file rather than a closed handle; if
+		// even that fails, Write retries the open on every call.
+		if reopenErr := f.open(); reopenErr != nil {
+			f.broken = true
+		}
+		return err
+	}
+
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		if f.config.Compress {
+			compressFile(backup)
+		}
+		f.pruneBackups()
+	}()
+	return nil
+}
+
+// backupName returns an unused backup name for the current time. Names have
+// millisecond resolution, so rotations within the same millisecond get a
+// numeric suffix rather than renaming over each other.
+func (f *RotatingFile) backupName() (string, error) {
+	base := f.config.Filename + "." + f.now().Format(backupTimeFormat)
+	for seq := 0; ; seq++ {
+		name := base
+		if seq > 0 {
+			name += "-" + strconv.Itoa(seq)
+		}
+		taken := false
+		for _, candidate := range []string{name, name + ".gz"} {
+			if _, err := os.Lstat(candidate); err == nil {
+				taken = true
+			} else if !os.IsNotExist(err) {
+				return "", err
+			}
+		}
+		if !taken {
+			return name, nil
+		}
+	}
+}
+
+func (f *RotatingFile) moveToBackup(backup string) error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(f.config.Filename, backup); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := f.open(); err != nil {
+		os.Rename(backup, f.config.Filename)
+		return err
+	}
+	return nil
+}
+
+type backupFile struct {
+	stem string
+	at   time.Time
+	seq  int
+}
+
+// pruneBackups removes the oldest backups beyond MaxBackups. Only names this
+// file created are considered, so files left by logrotate or other tools are
+// never touched.
+func (f *RotatingFile) pruneBackups() {
+	if f.config.MaxBackups <= 0 {
+		return
+	}
+
+	matches, err := filepath.Glob(f.config.Filename + ".*")
+	if err != nil {
+		return
+	}
+
+	seen := make(map[string]bool)
+	var backups []backupFile
+	for _, match := range matches {
+		backup, ok := f.parseBackup(match)
+		if !ok || seen[backup.stem] {
+			continue
+		}
+		seen[backup.stem] = true
+		backups = append(backups, backup)
+	}
+	sort.Slice(backups, func(i, j int) bool {
+		if !backups[i].at.Equal(backups[j].at) {
+			return backups[i].at.Before(backups[j].at)
+		}
+		return backups[i].seq < backups[j].seq
+	})
+
+	for len(backups) > f.config.MaxBackups {
+		os.Remove(backups[0].stem)
+		os.Remove(backups[0].stem + ".gz")
+		backups = backups[1:]
+	}
+}
+
+// parseBackup recognises names of the form Filename.<backupTimeFormat>,
+// optionally followed by a -<seq> suffix and a .gz extension.
+func (f *RotatingFile) parseBackup(name string) (backupFile, bool) {
+	stem := strings.TrimSuffix(name, ".gz")
+	suffix := strings.TrimPrefix(stem, f.config.Filename+".")
+	if len(suffix) < len(backupTimeFormat) {
+		return backupFile{}, false
+	}
+	at, err := time.Parse(backupTimeFormat, suffix[:len(backupTimeFormat)])
+	if err != nil {
+		return backupFile{}, false
+	}
+
+	seq := 0
+	if rest := suffix[len(backupTimeFormat):]; rest != "" {
+		digits, ok := strings.CutPrefix(rest, "-")
+		if !ok {
+			return backupFile{}, false
+		}
+		if seq, err = strconv.Atoi(digits); err != nil || seq <= 0 {
+			return backupFile{}, false
+		}
+	}
+	return backupFile{stem: stem, at: at, seq: seq}, true
+}
+
+// compressFile writes name.gz through a temporary file so a partially
+// written archive is never mistaken for a backup.
+func compressFile(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	tmp := name + ".gz.tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	gw := gzip.NewWriter(dst)
+	if _, err := io.Copy(gw, src); err != nil {
+		dst.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := gw.Close(); err != nil {
+		dst.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, name+".gz"); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Remove(name)
+}
@@ -0,0 +1,218 @@
+package simplerouter
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRotatingFileSize(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "access.log")
+
+	clock := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	f, err := NewRotatingFile(RotatingFileConfig{
+		Filename:   name,
+		MaxSize:    10,
+		MaxBackups: 2,
+	})
+	if err != nil {
+		t.Fatalf("NewRotatingFile returned error: %v", err)
+	}
+	f.now = func() time.Time {
+		clock = clock.Add(time.Second)
+		return clock
+	}
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatalf("Write returned error: %v", err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close returned error: %v", err)
+	}
+
+	current, _ := os.ReadFile(name)
+	if string(current) != "fourth\n" {
+		t.Errorf("Expected current file to contain %q, got %q", "fourth\n", current)
+	}
+
+	backups, _ := filepath.Glob(name + ".*")
+	if len(backups) != 2 {
+		t.Fatalf("Expected 2 backups, got %d: %v", len(backups), backups)
+	}
+	oldest, _ := os.ReadFile(backups[0])
+	if string(oldest) != "second\n" {
+		t.Errorf("Expected oldest kept backup to contain %q, got %q", "second\n", oldest)
+	}
+}
+
+func TestRotatingFileDailyCompress(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "access.log")
+
+	clock := time.Date(2026, 1, 1, 23, 59, 0, 0, time.UTC)
+	f, err := NewRotatingFile(RotatingFileConfig{
+		Filename: name,
+		Daily:    true,
+		Compress: true,
+	})
+	if err != nil {
+		t.Fatalf("NewRotatingFile returned error: %v", err)
+	}
+	f.now = func() time.Time { return clock }
+	f.openedAt = clock
+
+	f.Write([]byte("day one\n"))
+	clock = clock.Add(2 * time.Minute)
+	f.Write([]byte("day two\n"))
+	f.Close()
+
+	backups, _ := filepath.Glob(name + ".*")
+	if len(backups) != 1 || !strings.HasSuffix(backups[0], ".gz") {
+		t.Fatalf("Expected a single gzip backup, got %v", backups)
+	}
+
+	file, _ := os.Open(backups[0])
+	defer file.Close()
+	gr, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("Failed to open gzip backup: %v", err)
+	}
+	data, _ := io.ReadAll(gr)
+	if string(data) != "day one\n" {
+		t.Errorf("Expected backup to contain %q, got %q", "day one\n", data)
+	}
+
+	current, _ := os.ReadFile(name)
+	if string(current) != "day two\n" {
+		t.Errorf("Expected current file to contain %q, got %q", "day two\n", current)
+	}
+}
+
+func TestRotatingFileReopen(t *testing.T) {
+	dir := t.TempDir()
+	name := filepath.Join(dir, "access.log")
+
+	f, err := NewRotatingFile(RotatingFileConfig{Filename: name})
+	if err != nil {
+		t.Fatalf("NewRotatingFile returned error: %v", err)
+	}
+	defer f.Close()
+
+	f.Write([]byte("before\n"))
+	os.Rename(name, filepath.Join(dir, "access.log.1"))
+
+	if err := f.Reopen(); err != nil {
+		t.Fatalf("Reopen returned error: %v", err)
+	}
+	f.Write([]byte("after\n"))
+
+	current, _ := os.ReadFile(name)
+	if string(current) != "after\n" {
+		t.Errorf("Expected reopened file to contain %q, got %q", "after\n", current)
+	}
+	moved, _ := os.ReadFile(filepath.Join(dir, "access.log.1"))
+	if string(moved) != "before\n" {
+		t.Errorf("Expected moved file to contain %q, got %q", "before\n", moved)
+	}
+}
+
+func TestRotatingFileRotateFailure(t *testing.T) {
+	// The log name fits the filesystem's limit but its backup name does
+	// not, so creating the backup fails.
+	name := filepath.Join(t.TempDir(), strings.Repeat("a", 240))
+
+	f, err := NewRotatingFile(RotatingFileConfig{Filename: name})
+	if err != nil {
+		t.Fatalf("NewRotatingFile returned error: %v", err)
+	}
+	defer f.Close()
+
+	f.Write([]byte("before\n"))
+	if err := f.Rotate(); err == nil {
+		t.Fatal("Expected Rotate to fail when the backup cannot be created")
+	}
+	if _, err := f.Write([]byte("after\n")); err != nil {
+		t.Fatalf("Expected writes to continue after a failed rotation, got %v", err)
+	}
+
+	current, _ := os.ReadFile(name)
+	if string(current) != "before\nafter\n" {
+		t.Errorf("Expected original file to keep receiving writes, got %q", current)
+	}
+}
+
+func TestRotatingFileDailyAfterRestart(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "access.log")
+	if err := os.WriteFile(name, []byte("yesterday\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	yesterday := time.Now().Add(-24 * time.Hour)
+	if err := os.Chtimes(name, yesterday, yesterday); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := NewRotatingFile(RotatingFileConfig{Filename: name, Daily: true})
+	if err != nil {
+		t.Fatalf("NewRotatingFile returned error: %v", err)
+	}
+	f.Write([]byte("today\n"))
+	f.Close()
+
+	backups, _ := filepath.Glob(name + ".*")
+	if len(backups) != 1 {
+		t.Fatalf("Expected yesterday's file to be rotated on first write, got %v", backups)
+	}
+	current, _ := os.ReadFile(name)
+	if string(current) != "today\n" {
+		t.Errorf("Expected current file to contain %q, got %q", "today\n", current)
+	}
+}
+
+func TestRotatingFilePruneOwnBackupsOnly(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "access.log")
+	clock := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	foreign := []string{name + ".1", name + ".old", name + ".20260101-120000.000.gz.tmp"}
+	for _, file := range foreign {
+		if err := os.WriteFile(file, []byte("keep\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := NewRotatingFile(RotatingFileConfig{Filename: name, MaxBackups: 1})
+	if err != nil {
+		t.Fatalf("NewRotatingFile returned error: %v", err)
+	}
+	f.now = func() time.Time { return clock }
+
+	// Rotations within one millisecond must not overwrite each other.
+	for _, line := range []string{"one\n", "two\n", "three\n"} {
+		f.Write([]byte(line))
+		if err := f.Rotate(); err != nil {
+			t.Fatalf("Rotate returned error: %v", err)
+		}
+	}
+	f.Close()
+
+	for _, file := range foreign {
+		if _, err := os.Stat(file); err != nil {
+			t.Errorf("Expected %s to survive pruning, got %v", filepath.Base(file), err)
+		}
+	}
+
+	kept := name + "." + clock.Format(backupTimeFormat) + "-2"
+	data, err := os.ReadFile(kept)
+	if err != nil || string(data) != "three\n" {
+		t.Fatalf("Expected newest backup %s to contain %q, got %q (%v)", filepath.Base(kept), "three\n", data, err)
+	}
+	for _, pruned := range []string{"", "-1"} {
+		if _, err := os.Stat(name + "." + clock.Format(backupTimeFormat) + pruned); !os.IsNotExist(err) {
+			t.Errorf("Expected older backup %q to be pruned, got %v", pruned, err)
+		}
+	}
+}
@@ -1,9 +1,11 @@
 package simplerouter
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"os"
+	"slices"
 	"sort"
 	"strings"
 )
@@ -12,16 +14,33 @@ type Router struct {
 	mux         *http.ServeMux
 	prefix      string
 	middlewares []Middleware
-	routes      map[string]map[string]HandlerFunc
+	requires    []string
+	routes      map[string]map[string]*route
+	autoOptions map[string]*optionsRoute
 	routeInfo   *[]RouteInfo
 }
 
 type RouteInfo struct {
-	Method string
-	Path   string
-	Prefix string
+	Method   string
+	Path     string
+	Prefix   string
+	Name     string
+	Requires []string
 }
 
+type route struct {
+	handler     HandlerFunc
+	info        RouteInfo
+	middlewares []Middleware
+}
+
+type contextKey int
+
+const (
+	routeInfoKey contextKey = iota
+	routeMethodsKey
+)
+
 type HandlerFunc func(http.ResponseWriter, *http.Request)
 
 type Middleware func(HandlerFunc) HandlerFunc
@@ -32,7 +51,8 @@ func New() *Router {
 		mux:         http.NewServeMux(),
 		prefix:      "",
 		middlewares: make([]Middleware, 0),
-		routes:      make(map[string]map[string]HandlerFunc),
+		routes:      make(map[string]map[string]*route),
+		autoOptions: make(map[string]*optionsRoute),
 		routeInfo:   &routeInfo,
 	}
 }
@@ -84,49 +104,156 @@ func (r *Router) Group(prefix string) *Router {
 		mux:         r.mux,
 		prefix:      newPrefix,
 		middlewares: middlewares,
+		requires:    r.requires,
 		routes:      r.routes,
+		autoOptions: r.autoOptions,
 		routeInfo:   r.routeInfo,
 	}
 }
 
 func (r *Router) Handle(method, path string, handler HandlerFunc) {
+	r.handle(method, path, handler, "")
+}
+
+func (r *Router) handle(method, path string, handler HandlerFunc, name string) {
 	fullPath := r.joinPaths(r.prefix, path)
 
 	finalHandler := handler
+	if len(r.requires) > 0 {
+		finalHandler = enforceRequirements(r.requires, finalHandler)
+	}
 	for i := len(r.middlewares) - 1; i >= 0; i-- {
 		finalHandler = r.middlewares[i](finalHandler)
 	}
 
 	if r.routes[fullPath] == nil {
-		r.routes[fullPath] = make(map[string]HandlerFunc)
+		r.routes[fullPath] = make(map[string]*route)
 		r.mux.HandleFunc(fullPath, r.dispatch(fullPath))
 	}
 
-	r.routes[fullPath][method] = finalHandler
+	info := RouteInfo{
+		Method:   method,
+		Path:     fullPath,
+		Prefix:   r.prefix,
+		Name:     name,
+		Requires: slices.Clone(r.requires),
+	}
+	r.routes[fullPath][method] = &route{
+		handler:     finalHandler,
+		info:        info,
+		middlewares: r.middlewares,
+	}
+	if _, ok := r.routes[fullPath][http.MethodOptions]; ok {
+		delete(r.autoOptions, fullPath)
+	} else {
+		r.autoOptions[fullPath] = automaticOptions(r.routes[fullPath])
+	}
 
-	*r.routeInfo = append(*r.routeInfo, RouteInfo{
-		Method: method,
-		Path:   fullPath,
-		Prefix: r.prefix,
-	})
+	*r.routeInfo = append(*r.routeInfo, info)
 }
 
 func (r *Router) dispatch(path string) http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		methodHandlers := r.routes[path]
-		if handler, exists := methodHandlers[req.Method]; exists {
-			handler(w, req)
+		allowedMethods := routeMethods(methodHandlers)
+		ctx := context.WithValue(req.Context(), routeMethodsKey, allowedMethods)
+
+		if rt, exists := methodHandlers[req.Method]; exists {
+			req = req.WithContext(context.WithValue(ctx, routeInfoKey, rt.info))
+			rt.handler(w, req)
+		} else if options, exists := r.autoOptions[path]; exists && req.Method == http.MethodOptions {
+			rt := options.forRequest(req)
+			req = req.WithContext(context.WithValue(ctx, routeInfoKey, rt.info))
+			rt.handler(w, req)
 		} else {
-			allowedMethods := make([]string, 0, len(methodHandlers))
-			for method := range methodHandlers {
-				allowedMethods = append(allowedMethods, method)
-			}
 			w.Header().Set("Allow", strings.Join(allowedMethods, ", "))
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 		}
 	}
 }
 
+func routeMethods(methodHandlers map[string]*route) []string {
+	methods := make([]string, 0, len(methodHandlers)+1)
+	for method := range methodHandlers {
+		methods = append(methods, method)
+	}
+	if _, ok := methodHandlers[http.MethodOptions]; !ok {
+		methods = append(methods, http.MethodOptions)
+	}
+	sort.Strings(methods)
+	return methods
+}
+
+// optionsRoute holds the automatic OPTIONS handler for a path, built once per
+// registered method so a CORS preflight runs the middleware of the route it
+// asks about.
+type optionsRoute struct {
+	byMethod map[string]*route
+	fallback *route
+}
+
+// forRequest picks the chain of the method named in
+// Access-Control-Request-Method, falling back to the GET route's (or first
+// route's by method) for plain OPTIONS requests.
+func (o *optionsRoute) forRequest(req *http.Request) *route {
+	if rt, ok := o.byMethod[req.Header.Get("Access-Control-Request-Method")]; ok {
+		return rt
+	}
+	return o.fallback
+}
+
+// automaticOptions builds the OPTIONS handlers for paths without an explicit
+// one. It is rebuilt whenever a method is registered for the path.
+func automaticOptions(methodHandlers map[string]*route) *optionsRoute {
+	allowedMethods := routeMethods(methodHandlers)
+	allow := strings.Join(allowedMethods, ", ")
+
+	options := &optionsRoute{byMethod: make(map[string]*route, len(methodHandlers))}
+	for method, source := range methodHandlers {
+		options.byMethod[method] = optionsFor(source, allow)
+	}
+
+	if rt, ok := options.byMethod[http.MethodGet]; ok {
+		options.fallback = rt
+	} else {
+		for _, method := range allowedMethods {
+			if rt, exists := options.byMethod[method]; exists {
+				options.fallback = rt
+				break
+			}
+		}
+	}
+	return options
+}
+
+func optionsFor(source *route, allow string) *route {
+	handler := HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Allow", allow)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	for i := len(source.middlewares) - 1; i >= 0; i-- {
+		handler = source.middlewares[i](handler)
+	}
+
+	info := source.info
+	info.Method = http.MethodOptions
+	return &route{
+		handler: handler,
+		info:    info,
+	}
+}
+
+func routeMethodsFrom(ctx context.Context) []string {
+	methods, _ := ctx.Value(routeMethodsKey).([]string)
+	return methods
+}
+
+func RouteInfoFrom(ctx context.Context) (RouteInfo, bool) {
+	info, ok := ctx.Value(routeInfoKey).(RouteInfo)
+	info.Requires = slices.Clone(info.Requires)
+	return info, ok
+}
+
 func (r *Router) HandleFunc(method, path string, handler http.HandlerFunc) {
 	r.Handle(method, path, HandlerFunc(handler))
 }
@@ -140,7 +267,9 @@ func (r *Router) Use(middlewares ...Middleware) *Router {
 		mux:         r.mux,
 		prefix:      r.prefix,
 		middlewares: newMiddlewares,
+		requires:    r.requires,
 		routes:      r.routes,
+		autoOptions: r.autoOptions,
 		routeInfo:   r.routeInfo,
 	}
 }
@@ -159,6 +288,17 @@ func (r *Router) ListenAndServeTLS(addr, certFile, keyFile string) error {
 	return http.ListenAndServeTLS(addr, certFile, keyFile, r)
 }
 
+// Routes returns a copy of every registered route, including the
+// permissions each one requires.
+func (r *Router) Routes() []RouteInfo {
+	routes := make([]RouteInfo, len(*r.routeInfo))
+	copy(routes, *r.routeInfo)
+	for i := range routes {
+		routes[i].Requires = slices.Clone(routes[i].Requires)
+	}
+	return routes
+}
+
 func (r *Router) PrintRoutes() {
 	if len(*r.routeInfo) == 0 {
 		fmt.Println("No routes registered")
@@ -175,15 +315,15 @@ func (r *Router) PrintRoutes() {
 	})
 
 	fmt.Println("\n📋 Registered Routes:")
-	fmt.Println("┌─────────┬─────────────────────────────────────────────┐")
-	fmt.Println("│ Method  │ Path                                    │")
-	fmt.Println("├─────────┼─────────────────────────────────────────────┤")
+	fmt.Println("┌─────────┬─────────────────────────────────────────────┬──────────────────────────────┐")
+	fmt.Println("│ Method  │ Path                                        │ Requires                     │")
+	fmt.Println("├─────────┼─────────────────────────────────────────────┼──────────────────────────────┤")
 
 	for _, route := range sortedRoutes {
-		fmt.Printf("│ %-7s │ %-43s │\n", route.Method, route.Path)
+		fmt.Printf("│ %-7s │ %-43s │ %-28s │\n", route.Method, route.Path, strings.Join(route.Requires, ", "))
 	}
 
-	fmt.Println("└─────────┴─────────────────────────────────────────────┘")
+	fmt.Println("└─────────┴─────────────────────────────────────────────┴──────────────────────────────┘")
 	fmt.Printf("Total routes: %d\n\n", len(sortedRoutes))
 }
 
@@ -246,6 +386,8 @@ func (r *Router) OPTIONS(path string, handler HandlerFunc, middlewares ...Middle
 type RouteBuilder struct {
 	router      *Router
 	path        string
+	name        string
+	requires    []string
 	middlewares []Middleware
 }
 
@@ -262,30 +404,46 @@ func (rb *RouteBuilder) Use(middlewares ...Middleware) *RouteBuilder {
 	return rb
 }
 
+func (rb *RouteBuilder) Name(name string) *RouteBuilder {
+	rb.name = name
+	return rb
+}
+
+func (rb *RouteBuilder) handle(method string, handler HandlerFunc) {
+	router := rb.router
+	if len(rb.middlewares) > 0 {
+		router = router.With(rb.middlewares...)
+	}
+	if len(rb.requires) > 0 {
+		router = router.Require(rb.requires...)
+	}
+	router.handle(method, rb.path, handler, rb.name)
+}
+
 func (rb *RouteBuilder) GET(handler HandlerFunc) {
-	rb.router.GET(rb.path, handler, rb.middlewares...)
+	rb.handle("GET", handler)
 }
 
 func (rb *RouteBuilder) POST(handler HandlerFunc) {
-	rb.router.POST(rb.path, handler, rb.middlewares...)
+	rb.handle("POST", handler)
 }
 
 func (rb *RouteBuilder) PUT(handler HandlerFunc) {
-	rb.router.PUT(rb.path, handler, rb.middlewares...)
+	rb.handle("PUT", handler)
 }
 
 func (rb *RouteBuilder) DELETE(handler HandlerFunc) {
-	rb.router.DELETE(rb.path, handler, rb.middlewares...)
+	rb.handle("DELETE", handler)
 }
 
 func (rb *RouteBuilder) PATCH(handler HandlerFunc) {
-	rb.router.PATCH(rb.path, handler, rb.middlewares...)
+	rb.handle("PATCH", handler)
 }
 
 func (rb *RouteBuilder) HEAD(handler HandlerFunc) {
-	rb.router.HEAD(rb.path, handler, rb.middlewares...)
+	rb.handle("HEAD", handler)
 }
 
 func (rb *RouteBuilder) OPTIONS(handler HandlerFunc) {
-	rb.router.OPTIONS(rb.path, handler, rb.middlewares...)
+	rb.handle("OPTIONS", handler)
 }
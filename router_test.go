@@ -507,3 +507,56 @@ func TestMiddlewareExecutionOrder(t *testing.T) {
 		}
 	}
 }
+
+func TestAutomaticOptions(t *testing.T) {
+	router := New()
+
+	var middlewareRan bool
+	marker := func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			middlewareRan = true
+			next(w, r)
+		}
+	}
+
+	router.GET("/items", func(w http.ResponseWriter, r *http.Request) {}, marker)
+	router.DELETE("/items", func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest("OPTIONS", "/items", nil)
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNoContent {
+		t.Errorf("Expected status %d, got %d", http.StatusNoContent, rr.Code)
+	}
+	if rr.Header().Get("Allow") != "DELETE, GET, OPTIONS" {
+		t.Errorf("Expected Allow header %q, got %q", "DELETE, GET, OPTIONS", rr.Header().Get("Allow"))
+	}
+	if !middlewareRan {
+		t.Error("Expected the GET route's middleware to run for automatic OPTIONS")
+	}
+}
+
+func TestAutomaticOptionsBuiltOnce(t *testing.T) {
+	router := New()
+
+	built := 0
+	counting := func(next HandlerFunc) HandlerFunc {
+		built++
+		return next
+	}
+	router.GET("/items", func(w http.ResponseWriter, r *http.Request) {}, counting)
+	built = 0
+
+	for i := 0; i < 3; i++ {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("OPTIONS", "/items", nil))
+		if rr.Code != http.StatusNoContent {
+			t.Fatalf("Expected status %d, got %d", http.StatusNoContent, rr.Code)
+		}
+	}
+	if built != 0 {
+		t.Errorf("Expected automatic OPTIONS chain to be built at registration, built %d times per request", built)
+	}
+}
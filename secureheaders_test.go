@@ -0,0 +1,89 @@
+package simplerouter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestSecureHeadersAPIPreset(t *testing.T) {
+	router := New().Use(SecureHeaders(APISecureHeaders()))
+	router.GET("/api", func(w http.ResponseWriter, r *http.Request) {
+		if CSPNonceFrom(r.Context()) != "" {
+			t.Error("Expected no nonce when the policy does not use one")
+		}
+	})
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api", nil))
+
+	expected := map[string]string{
+		"Strict-Transport-Security":    "max-age=63072000; includeSubDomains",
+		"X-Content-Type-Options":       "nosniff",
+		"X-Frame-Options":              "DENY",
+		"Referrer-Policy":              "no-referrer",
+		"Cross-Origin-Opener-Policy":   "same-origin",
+		"Cross-Origin-Resource-Policy": "same-origin",
+		"Content-Security-Policy":      "default-src 'none'; frame-ancestors 'none'",
+	}
+	for key, value := range expected {
+		if got := rr.Header().Get(key); got != value {
+			t.Errorf("Expected %s %q, got %q", key, value, got)
+		}
+	}
+	if rr.Header().Get("Permissions-Policy") != "" {
+		t.Error("Expected unset options to be omitted")
+	}
+}
+
+func TestSecureHeadersCSPNonce(t *testing.T) {
+	router := New().Use(SecureHeaders(WebAppSecureHeaders()))
+
+	var nonces []string
+	router.GET("/page", func(w http.ResponseWriter, r *http.Request) {
+		nonces = append(nonces, CSPNonceFrom(r.Context()))
+	})
+
+	var policies []string
+	for i := 0; i < 2; i++ {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/page", nil))
+		policies = append(policies, rr.Header().Get("Content-Security-Policy"))
+	}
+
+	if nonces[0] == "" || nonces[0] == nonces[1] {
+		t.Fatalf("Expected a fresh nonce per request, got %q and %q", nonces[0], nonces[1])
+	}
+	for i, policy := range policies {
+		want := "script-src 'self' 'nonce-" + nonces[i] + "' 'strict-dynamic'"
+		if !strings.Contains(policy, want) {
+			t.Errorf("Expected policy to contain %q, got %q", want, policy)
+		}
+		if strings.Contains(policy, CSPNonce) {
+			t.Errorf("Expected nonce placeholder to be replaced, got %q", policy)
+		}
+	}
+}
+
+func TestContentSecurityPolicyReportOnly(t *testing.T) {
+	csp := NewContentSecurityPolicy().
+		Add("default-src", "'self'").
+		Add("img-src", "'self'").
+		Add("img-src", "https://cdn.example.com")
+	csp.ReportOnly = true
+
+	router := New().Use(SecureHeaders(SecureHeadersConfig{ContentSecurityPolicy: csp}))
+	router.GET("/", func(w http.ResponseWriter, r *http.Request) {})
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+
+	want := "default-src 'self'; img-src 'self' https://cdn.example.com"
+	if got := rr.Header().Get("Content-Security-Policy-Report-Only"); got != want {
+		t.Errorf("Expected report-only policy %q, got %q", want, got)
+	}
+	if rr.Header().Get("Content-Security-Policy") != "" {
+		t.Error("Expected no enforcing policy in report-only mode")
+	}
+}
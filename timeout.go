@@ -0,0 +1,161 @@
+package simplerouter
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type TimeoutConfig struct {
+	Timeout     time.Duration
+	StatusCode  int
+	Body        string
+	ContentType string
+}
+
+// Timeout runs the handler with a context deadline and buffers its response,
+// replying with StatusCode (503 by default) if the deadline passes first.
+// Flushing commits the buffered response, after which output streams
+// directly and a timeout can no longer replace it.
+func Timeout(config TimeoutConfig) Middleware {
+	if config.StatusCode == 0 {
+		config.StatusCode = http.StatusServiceUnavailable
+	}
+	if config.Body == "" {
+		config.Body = http.StatusText(config.StatusCode)
+	}
+	if config.ContentType == "" {
+		config.ContentType = "text/plain; charset=utf-8"
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), config.Timeout)
+			defer cancel()
+			r = r.WithContext(ctx)
+
+			tw := &timeoutWriter{
+				w:      w,
+				header: make(http.Header),
+				ctx:    ctx,
+			}
+
+			done := make(chan struct{})
+			panicked := make(chan any, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+				next(tw, r)
+				close(done)
+			}()
+
+			select {
+			case p := <-panicked:
+				panic(p)
+			case <-done:
+			case <-ctx.Done():
+			}
+
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+
+			// A handler that returns after the deadline is treated as timed
+			// out too, since any writes it made late were rejected.
+			if ctx.Err() == nil {
+				if !tw.committed {
+					tw.commit()
+				}
+				return
+			}
+
+			tw.timedOut = true
+			LogError(r, ctx.Err())
+			if tw.committed {
+				return
+			}
+			w.Header().Set("Content-Type", config.ContentType)
+			w.WriteHeader(config.StatusCode)
+			w.Write([]byte(config.Body))
+		}
+	}
+}
+
+type timeoutWriter struct {
+	w           http.ResponseWriter
+	ctx         context.Context
+	header      http.Header
+	mu          sync.Mutex
+	buf         bytes.Buffer
+	status      int
+	wroteHeader bool
+	committed   bool
+	timedOut    bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) WriteHeader(status int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.writeHeader(status)
+}
+
+func (tw *timeoutWriter) writeHeader(status int) {
+	if tw.timedOut || tw.wroteHeader || tw.ctx.Err() != nil {
+		return
+	}
+	tw.wroteHeader = true
+	tw.status = status
+}
+
+func (tw *timeoutWriter) Write(b []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut || tw.ctx.Err() != nil {
+		return 0, http.ErrHandlerTimeout
+	}
+	tw.writeHeader(http.StatusOK)
+	if tw.committed {
+		return tw.w.Write(b)
+	}
+	return tw.buf.Write(b)
+}
+
+func (tw *timeoutWriter) Flush() {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut || tw.ctx.Err() != nil {
+		return
+	}
+	if !tw.committed {
+		tw.writeHeader(http.StatusOK)
+		tw.commit()
+	}
+	if flusher, ok := tw.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (tw *timeoutWriter) commit() {
+	dst := tw.w.Header()
+	for key, values := range tw.header {
+		dst[key] = values
+	}
+	status := tw.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	tw.w.WriteHeader(status)
+	tw.w.Write(tw.buf.Bytes())
+	tw.buf.Reset()
+	tw.committed = true
+}
@@ -0,0 +1,147 @@
+package simplerouter
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	router := New()
+	handlerDone := make(chan error, 1)
+
+	router.Route("/slow").Use(Timeout(TimeoutConfig{
+		Timeout: 20 * time.Millisecond,
+		Body:    "too slow",
+	})).GET(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		w.Header().Set("X-Late", "true")
+		_, err := w.Write([]byte("late response"))
+		handlerDone <- err
+	})
+
+	router.Group("/api").Use(Timeout(TimeoutConfig{
+		Timeout: time.Second,
+	})).GET("/fast", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Fast", "true")
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("fast response"))
+	})
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/slow", nil))
+
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, rr.Code)
+	}
+	if rr.Body.String() != "too slow" {
+		t.Errorf("Expected timeout body %q, got %q", "too slow", rr.Body.String())
+	}
+	if err := <-handlerDone; err != http.ErrHandlerTimeout {
+		t.Errorf("Expected late write to fail with ErrHandlerTimeout, got %v", err)
+	}
+	if rr.Header().Get("X-Late") != "" || rr.Body.String() != "too slow" {
+		t.Error("Expected late handler output to be discarded")
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/api/fast", nil))
+
+	if rr.Code != http.StatusCreated || rr.Body.String() != "fast response" || rr.Header().Get("X-Fast") != "true" {
+		t.Errorf("Expected buffered response to be copied through, got %d %q", rr.Code, rr.Body.String())
+	}
+}
+
+func TestTimeoutGatewayStatus(t *testing.T) {
+	router := New()
+	router.GET("/slow", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}, Timeout(TimeoutConfig{Timeout: 10 * time.Millisecond, StatusCode: http.StatusGatewayTimeout}))
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/slow", nil))
+
+	if rr.Code != http.StatusGatewayTimeout {
+		t.Errorf("Expected status %d, got %d", http.StatusGatewayTimeout, rr.Code)
+	}
+}
+
+func TestTimeoutFlushStreams(t *testing.T) {
+	router := New()
+	ctxErr := make(chan error, 1)
+
+	router.GET("/export", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("header row\n"))
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+		ctxErr <- r.Context().Err()
+	}, Timeout(TimeoutConfig{Timeout: 20 * time.Millisecond}))
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/export", nil))
+
+	if !rr.Flushed {
+		t.Error("Expected Flush to reach the underlying writer")
+	}
+	if rr.Code != http.StatusOK || rr.Body.String() != "header row\n" {
+		t.Errorf("Expected streamed output to be kept, got %d %q", rr.Code, rr.Body.String())
+	}
+	if err := <-ctxErr; err != context.DeadlineExceeded {
+		t.Errorf("Expected context deadline to be exceeded, got %v", err)
+	}
+}
+
+func TestTimeoutPanicPropagates(t *testing.T) {
+	router := New()
+	router.GET("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("handler failure")
+	}, Timeout(TimeoutConfig{Timeout: time.Second}))
+
+	defer func() {
+		if recovered := recover(); recovered != "handler failure" {
+			t.Errorf("Expected panic to propagate, got %v", recovered)
+		}
+	}()
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/panic", nil))
+}
+
+type slowReader struct {
+	remaining int
+}
+
+func (s *slowReader) Read(p []byte) (int, error) {
+	if s.remaining == 0 {
+		return 0, io.EOF
+	}
+	time.Sleep(time.Millisecond)
+	s.remaining--
+	p[0] = 'a'
+	return 1, nil
+}
+
+func TestTimeoutWithAccessLogging(t *testing.T) {
+	var buf bytes.Buffer
+	handlerDone := make(chan struct{})
+	router := New().Use(
+		AccessLogging(AccessLogConfig{Output: &buf, BodyCapture: &BodyCaptureConfig{}}),
+		Timeout(TimeoutConfig{Timeout: 10 * time.Millisecond}),
+	)
+	router.POST("/upload", func(w http.ResponseWriter, r *http.Request) {
+		defer close(handlerDone)
+		io.ReadAll(r.Body)
+	})
+
+	req := httptest.NewRequest("POST", "/upload", &slowReader{remaining: 50})
+	req.Header.Set("Content-Type", "text/plain")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	<-handlerDone
+
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("Expected status %d, got %d", http.StatusServiceUnavailable, rr.Code)
+	}
+}
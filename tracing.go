@@ -0,0 +1,203 @@
+package simplerouter
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+type TraceID [16]byte
+
+func (id TraceID) IsValid() bool {
+	return id != TraceID{}
+}
+
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+type SpanID [8]byte
+
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+type SpanContext struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Sampled    bool
+	TraceState string
+	Remote     bool
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+type Span interface {
+	SpanContext() SpanContext
+	SetAttribute(key string, value any)
+	End()
+}
+
+// Tracer starts spans for incoming requests. parent is the remote span
+// context from the traceparent header and is invalid when none was sent.
+type Tracer interface {
+	Start(ctx context.Context, name string, parent SpanContext) (context.Context, Span)
+}
+
+type TracingConfig struct {
+	Tracer Tracer
+}
+
+type spanContextKey struct{}
+
+func Tracing(config TracingConfig) Middleware {
+	tracer := config.Tracer
+	if tracer == nil {
+		tracer = propagatingTracer{}
+	}
+
+	return func(next HandlerFunc) HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			parent, _ := ParseTraceParent(r.Header.Get("traceparent"))
+			if parent.IsValid() {
+				parent.TraceState = strings.TrimSpace(r.Header.Get("tracestate"))
+			}
+
+			name := r.Method
+			if info, ok := RouteInfoFrom(r.Context()); ok {
+				name += " " + info.Path
+			}
+
+			ctx, span := tracer.Start(r.Context(), name, parent)
+			sc := span.SpanContext()
+			ctx = context.WithValue(ctx, spanContextKey{}, sc)
+			r = r.WithContext(ctx)
+
+			span.SetAttribute("http.request.method", r.Method)
+			span.SetAttribute("url.path", r.URL.Path)
+			if info, ok := RouteInfoFrom(ctx); ok {
+				span.SetAttribute("http.route", info.Path)
+			}
+
+			setLogTrace(r, sc)
+			InjectTraceContext(ctx, w.Header())
+
+			wrapped, ww := wrapResponseWriter(w)
+			completed := false
+			defer func() {
+				status := wrapped.Status()
+				if status == 0 {
+					status = http.StatusOK
+				}
+				if !completed {
+					status = http.StatusInternalServerError
+				}
+				span.SetAttribute("http.response.status_code", status)
+				span.End()
+			}()
+
+			next(ww, r)
+			completed = true
+		}
+	}
+}
+
+func SpanContextFrom(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc, ok
+}
+
+// InjectTraceContext writes the traceparent and tracestate headers for the
+// span in ctx, for propagation to outgoing requests.
+func InjectTraceContext(ctx context.Context, header http.Header) {
+	sc, ok := SpanContextFrom(ctx)
+	if !ok || !sc.IsValid() {
+		return
+	}
+	header.Set("traceparent", FormatTraceParent(sc))
+	if sc.TraceState != "" {
+		header.Set("tracestate", sc.TraceState)
+	}
+}
+
+func ParseTraceParent(value string) (SpanContext, bool) {
+	value = strings.TrimSpace(value)
+	parts := strings.Split(value, "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return SpanContext{}, false
+	}
+
+	version, err := hex.DecodeString(parts[0])
+	if err != nil || version[0] == 0xff {
+		return SpanContext{}, false
+	}
+	// Version 00 has exactly four fields; later versions may append more.
+	if version[0] == 0 && len(parts) != 4 {
+		return SpanContext{}, false
+	}
+	for _, part := range parts[:4] {
+		if strings.ToLower(part) != part {
+			return SpanContext{}, false
+		}
+	}
+
+	var sc SpanContext
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return SpanContext{}, false
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return SpanContext{}, false
+	}
+	flags, err := hex.DecodeString(parts[3])
+	if err != nil {
+		return SpanContext{}, false
+	}
+	if !sc.IsValid() {
+		return SpanContext{}, false
+	}
+
+	sc.Sampled = flags[0]&0x01 == 0x01
+	sc.Remote = true
+	return sc, true
+}
+
+func FormatTraceParent(sc SpanContext) string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID.String() + "-" + sc.SpanID.String() + "-" + flags
+}
+
+// propagatingTracer continues or starts traces without recording spans, so
+// trace IDs flow through logs and headers when no Tracer is configured.
+type propagatingTracer struct{}
+
+func (propagatingTracer) Start(ctx context.Context, name string, parent SpanContext) (context.Context, Span) {
+	sc := SpanContext{Sampled: true}
+	if parent.IsValid() {
+		sc.TraceID = parent.TraceID
+		sc.Sampled = parent.Sampled
+		sc.TraceState = parent.TraceState
+	} else {
+		rand.Read(sc.TraceID[:])
+	}
+	rand.Read(sc.SpanID[:])
+	return ctx, propagatingSpan{sc}
+}
+
+type propagatingSpan struct {
+	sc SpanContext
+}
+
+func (s propagatingSpan) SpanContext() SpanContext           { return s.sc }
+func (s propagatingSpan) SetAttribute(key string, value any) {}
+func (s propagatingSpan) End()                               {}
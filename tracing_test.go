@@ -0,0 +1,175 @@
+package simplerouter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type recordingTracer struct {
+	spans []*recordingSpan
+}
+
+func (t *recordingTracer) Start(ctx context.Context, name string, parent SpanContext) (context.Context, Span) {
+	span := &recordingSpan{name: name, parent: parent, attributes: map[string]any{}}
+	span.sc = SpanContext{TraceID: parent.TraceID, SpanID: SpanID{1, 2, 3, 4, 5, 6, 7, 8}, Sampled: true}
+	if !parent.IsValid() {
+		span.sc.TraceID = TraceID{9}
+	}
+	t.spans = append(t.spans, span)
+	return ctx, span
+}
+
+type recordingSpan struct {
+	name       string
+	parent     SpanContext
+	sc         SpanContext
+	attributes map[string]any
+	ended      bool
+}
+
+func (s *recordingSpan) SpanContext() SpanContext           { return s.sc }
+func (s *recordingSpan) SetAttribute(key string, value any) { s.attributes[key] = value }
+func (s *recordingSpan) End()                               { s.ended = true }
+
+func TestParseTraceParent(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		expectValid bool
+		expectSampl bool
+	}{
+		{"valid sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", true, true},
+		{"valid unsampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00", true, false},
+		{"future version with extra field", "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", true, true},
+		{"version 00 with extra field", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", false, false},
+		{"invalid version ff", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", false, false},
+		{"zero trace id", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", false, false},
+		{"zero span id", "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", false, false},
+		{"uppercase hex", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", false, false},
+		{"short trace id", "00-4bf92f35-00f067aa0ba902b7-01", false, false},
+		{"empty", "", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, ok := ParseTraceParent(tt.value)
+			if ok != tt.expectValid {
+				t.Fatalf("Expected valid=%v, got %v", tt.expectValid, ok)
+			}
+			if ok && sc.Sampled != tt.expectSampl {
+				t.Errorf("Expected sampled=%v, got %v", tt.expectSampl, sc.Sampled)
+			}
+			if ok && tt.value[:2] == "00" && FormatTraceParent(sc) != tt.value {
+				t.Errorf("Expected round trip %q, got %q", tt.value, FormatTraceParent(sc))
+			}
+		})
+	}
+}
+
+func TestTracingPropagation(t *testing.T) {
+	router := New().Use(Tracing(TracingConfig{}))
+
+	var sc SpanContext
+	var outbound http.Header
+	router.GET("/test", func(w http.ResponseWriter, r *http.Request) {
+		sc, _ = SpanContextFrom(r.Context())
+		outbound = http.Header{}
+		InjectTraceContext(r.Context(), outbound)
+	})
+
+	parent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	req := httptest.NewRequest("GET", "/test", nil)
+	req.Header.Set("traceparent", parent)
+	req.Header.Set("tracestate", "vendor=value")
+	rr := httptest.NewRecorder()
+
+	router.ServeHTTP(rr, req)
+
+	if sc.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Expected trace ID to be continued, got %s", sc.TraceID)
+	}
+	if sc.SpanID.String() == "00f067aa0ba902b7" || !sc.SpanID.IsValid() {
+		t.Errorf("Expected a new span ID, got %s", sc.SpanID)
+	}
+	if !strings.HasPrefix(outbound.Get("traceparent"), "00-4bf92f3577b34da6a3ce929d0e0e4736-"+sc.SpanID.String()) {
+		t.Errorf("Unexpected outbound traceparent %q", outbound.Get("traceparent"))
+	}
+	if outbound.Get("tracestate") != "vendor=value" {
+		t.Errorf("Expected tracestate to propagate, got %q", outbound.Get("tracestate"))
+	}
+	if rr.Header().Get("traceparent") != outbound.Get("traceparent") {
+		t.Errorf("Expected response traceparent %q, got %q", outbound.Get("traceparent"), rr.Header().Get("traceparent"))
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/test", nil))
+	if !sc.IsValid() || sc.TraceID.String() == "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("Expected a new trace when no traceparent is sent, got %+v", sc)
+	}
+}
+
+func TestTracingCustomTracer(t *testing.T) {
+	tracer := &recordingTracer{}
+	var buf bytes.Buffer
+	router := New().Use(
+		AccessLogging(AccessLogConfig{Output: &buf, Format: JSONLogFormat}),
+		Tracing(TracingConfig{Tracer: tracer}),
+	)
+
+	router.GET("/users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/users/42", nil))
+
+	if len(tracer.spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(tracer.spans))
+	}
+	span := tracer.spans[0]
+	if span.name != "GET /users/{id}" {
+		t.Errorf("Expected span name %q, got %q", "GET /users/{id}", span.name)
+	}
+	if !span.ended {
+		t.Error("Expected span to be ended")
+	}
+	if span.attributes["http.route"] != "/users/{id}" || span.attributes["http.response.status_code"] != http.StatusAccepted {
+		t.Errorf("Unexpected span attributes %v", span.attributes)
+	}
+
+	var entry AccessLogEntry
+	if err := json.Unmarshal(buf.Bytes(), &entry); err != nil {
+		t.Fatalf("Failed to decode log entry: %v", err)
+	}
+	if entry.TraceID != span.sc.TraceID.String() || entry.SpanID != span.sc.SpanID.String() {
+		t.Errorf("Expected trace %s/%s in access log, got %s/%s", span.sc.TraceID, span.sc.SpanID, entry.TraceID, entry.SpanID)
+	}
+}
+
+func TestTracingPanicStatus(t *testing.T) {
+	tracer := &recordingTracer{}
+	router := New().Use(
+		Recovery(RecoveryConfig{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}),
+		Tracing(TracingConfig{Tracer: tracer}),
+	)
+
+	router.GET("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/panic", nil))
+
+	if len(tracer.spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(tracer.spans))
+	}
+	span := tracer.spans[0]
+	if !span.ended || span.attributes["http.response.status_code"] != http.StatusInternalServerError {
+		t.Errorf("Expected ended span with status 500, got %v", span.attributes)
+	}
+}